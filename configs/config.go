@@ -2,10 +2,14 @@ package configs
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 )
 
 type Config struct {
@@ -14,6 +18,36 @@ type Config struct {
 	RedisAddress  string
 	RedisPassword string
 	RedisDB       int
+
+	IdempotencyStore           string
+	IdempotencyTTL             time.Duration
+	IdempotencyCleanupInterval time.Duration
+
+	BalanceReconciliationInterval time.Duration
+
+	ErrorFormat string
+
+	HoldDefaultTTL     time.Duration
+	HoldMaxTTL         time.Duration
+	HoldExpiryInterval time.Duration
+
+	DepositClearingDelay    time.Duration
+	DepositClearingInterval time.Duration
+
+	RateProvider string
+	RateFile     string
+	FXQuoteTTL   time.Duration
+	FXSpread     decimal.Decimal
+
+	AuthJWTSecret      string
+	AuthInsecureHeader bool
+
+	RequestSigning   bool
+	SignatureMaxSkew time.Duration
+
+	ApprovalTransferThresholds map[string]decimal.Decimal
+	ApprovalTTL                time.Duration
+	ApprovalExpiryInterval     time.Duration
 }
 
 func InitConfig() (Config, error) {
@@ -30,21 +64,215 @@ func InitConfig() (Config, error) {
 		return Config{}, errors.New("DATABASE_URL is not set")
 	}
 
-	if os.Getenv("REDIS_ADDR") == "" {
+	idempotencyStore := os.Getenv("IDEMPOTENCY_STORE")
+	if idempotencyStore == "" {
+		// DEFAULT TO REDIS
+		idempotencyStore = "redis"
+	}
+	if idempotencyStore != "redis" && idempotencyStore != "postgres" {
+		return Config{}, errors.New("IDEMPOTENCY_STORE must be redis or postgres")
+	}
+
+	requestSigning, err := strconv.ParseBool(os.Getenv("REQUEST_SIGNING"))
+	if err != nil {
+		// DEFAULT TO FALSE
+		requestSigning = false
+	}
+
+	// Redis backs the redis idempotency store and the nonces of signed requests
+	if (idempotencyStore == "redis" || requestSigning) && os.Getenv("REDIS_ADDR") == "" {
 		return Config{}, errors.New("REDIS_ADDR is not set")
 	}
 
+	signatureMaxSkew, err := time.ParseDuration(os.Getenv("SIGNATURE_MAX_SKEW"))
+	if err != nil || signatureMaxSkew <= 0 {
+		// DEFAULT TO 5 MINUTES
+		signatureMaxSkew = 5 * time.Minute
+	}
+
 	redisDB, err := strconv.ParseInt(os.Getenv("REDIS_DB"), 10, 64)
 	if err != nil {
 		// DEFAULT TO 0
 		redisDB = 0
 	}
 
+	idempotencyTTL, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL"))
+	if err != nil || idempotencyTTL <= 0 {
+		// DEFAULT TO 24 HOURS
+		idempotencyTTL = 24 * time.Hour
+	}
+
+	idempotencyCleanupInterval, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_CLEANUP_INTERVAL"))
+	if err != nil || idempotencyCleanupInterval <= 0 {
+		// DEFAULT TO 1 HOUR
+		idempotencyCleanupInterval = time.Hour
+	}
+
+	balanceReconciliationInterval, err := time.ParseDuration(os.Getenv("BALANCE_RECONCILIATION_INTERVAL"))
+	if err != nil || balanceReconciliationInterval <= 0 {
+		// DEFAULT TO 1 HOUR
+		balanceReconciliationInterval = time.Hour
+	}
+
+	errorFormat := os.Getenv("ERROR_FORMAT")
+	if errorFormat == "" {
+		// DEFAULT TO PROBLEM+JSON
+		errorFormat = "problem"
+	}
+	if errorFormat != "problem" && errorFormat != "legacy" {
+		return Config{}, errors.New("ERROR_FORMAT must be problem or legacy")
+	}
+
+	holdDefaultTTL, err := time.ParseDuration(os.Getenv("HOLD_DEFAULT_TTL"))
+	if err != nil || holdDefaultTTL <= 0 {
+		// DEFAULT TO 7 DAYS
+		holdDefaultTTL = 7 * 24 * time.Hour
+	}
+
+	holdMaxTTL, err := time.ParseDuration(os.Getenv("HOLD_MAX_TTL"))
+	if err != nil || holdMaxTTL <= 0 {
+		// DEFAULT TO 30 DAYS
+		holdMaxTTL = 30 * 24 * time.Hour
+	}
+	if holdDefaultTTL > holdMaxTTL {
+		return Config{}, errors.New("HOLD_DEFAULT_TTL must not be longer than HOLD_MAX_TTL")
+	}
+
+	holdExpiryInterval, err := time.ParseDuration(os.Getenv("HOLD_EXPIRY_INTERVAL"))
+	if err != nil || holdExpiryInterval <= 0 {
+		// DEFAULT TO 1 MINUTE
+		holdExpiryInterval = time.Minute
+	}
+
+	depositClearingDelay, err := time.ParseDuration(os.Getenv("DEPOSIT_CLEARING_DELAY"))
+	if err != nil || depositClearingDelay < 0 {
+		// DEFAULT TO 0, DEPOSITS ARE AVAILABLE IMMEDIATELY
+		depositClearingDelay = 0
+	}
+
+	depositClearingInterval, err := time.ParseDuration(os.Getenv("DEPOSIT_CLEARING_INTERVAL"))
+	if err != nil || depositClearingInterval <= 0 {
+		// DEFAULT TO 1 MINUTE
+		depositClearingInterval = time.Minute
+	}
+
+	rateProvider := os.Getenv("RATE_PROVIDER")
+	if rateProvider == "" {
+		// DEFAULT TO POSTGRES
+		rateProvider = "postgres"
+	}
+	if rateProvider != "postgres" && rateProvider != "file" {
+		return Config{}, errors.New("RATE_PROVIDER must be postgres or file")
+	}
+
+	if rateProvider == "file" && os.Getenv("RATE_FILE") == "" {
+		return Config{}, errors.New("RATE_FILE is not set")
+	}
+
+	fxQuoteTTL, err := time.ParseDuration(os.Getenv("FX_QUOTE_TTL"))
+	if err != nil || fxQuoteTTL <= 0 {
+		// DEFAULT TO 30 SECONDS
+		fxQuoteTTL = 30 * time.Second
+	}
+
+	fxSpread, err := decimal.NewFromString(os.Getenv("FX_SPREAD"))
+	if err != nil {
+		// DEFAULT TO 0.5%
+		fxSpread = decimal.RequireFromString("0.005")
+	}
+	if fxSpread.IsNegative() || fxSpread.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return Config{}, errors.New("FX_SPREAD must be between 0 and 1")
+	}
+
+	// Only for local development, anyone can act on any wallet through X-Wallet-ID
+	authInsecureHeader, err := strconv.ParseBool(os.Getenv("AUTH_INSECURE_HEADER"))
+	if err != nil {
+		// DEFAULT TO FALSE
+		authInsecureHeader = false
+	}
+
+	authJWTSecret := os.Getenv("AUTH_JWT_SECRET")
+	if authJWTSecret != "" && len(authJWTSecret) < 32 {
+		return Config{}, errors.New("AUTH_JWT_SECRET must be at least 32 bytes")
+	}
+
+	// DEFAULT TO NONE, TRANSFERS NEVER NEED AN APPROVAL
+	approvalTransferThresholds, err := parseCurrencyAmounts(os.Getenv("APPROVAL_TRANSFER_THRESHOLDS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("APPROVAL_TRANSFER_THRESHOLDS %w", err)
+	}
+
+	approvalTTL, err := time.ParseDuration(os.Getenv("APPROVAL_TTL"))
+	if err != nil || approvalTTL <= 0 {
+		// DEFAULT TO 24 HOURS
+		approvalTTL = 24 * time.Hour
+	}
+
+	approvalExpiryInterval, err := time.ParseDuration(os.Getenv("APPROVAL_EXPIRY_INTERVAL"))
+	if err != nil || approvalExpiryInterval <= 0 {
+		// DEFAULT TO 1 MINUTE
+		approvalExpiryInterval = time.Minute
+	}
+
 	return Config{
 		Port:          os.Getenv("PORT"),
 		DatabaseURL:   os.Getenv("DATABASE_URL"),
 		RedisAddress:  os.Getenv("REDIS_ADDR"),
 		RedisPassword: os.Getenv("REDIS_PASSWORD"),
 		RedisDB:       int(redisDB),
+
+		IdempotencyStore:           idempotencyStore,
+		IdempotencyTTL:             idempotencyTTL,
+		IdempotencyCleanupInterval: idempotencyCleanupInterval,
+
+		BalanceReconciliationInterval: balanceReconciliationInterval,
+
+		ErrorFormat: errorFormat,
+
+		HoldDefaultTTL:     holdDefaultTTL,
+		HoldMaxTTL:         holdMaxTTL,
+		HoldExpiryInterval: holdExpiryInterval,
+
+		DepositClearingDelay:    depositClearingDelay,
+		DepositClearingInterval: depositClearingInterval,
+
+		RateProvider: rateProvider,
+		RateFile:     os.Getenv("RATE_FILE"),
+		FXQuoteTTL:   fxQuoteTTL,
+		FXSpread:     fxSpread,
+
+		AuthJWTSecret:      authJWTSecret,
+		AuthInsecureHeader: authInsecureHeader,
+
+		RequestSigning:   requestSigning,
+		SignatureMaxSkew: signatureMaxSkew,
+
+		ApprovalTransferThresholds: approvalTransferThresholds,
+		ApprovalTTL:                approvalTTL,
+		ApprovalExpiryInterval:     approvalExpiryInterval,
 	}, nil
 }
+
+// parseCurrencyAmounts reads a list like "USD:10000,JPY:1500000" into positive amounts per currency
+func parseCurrencyAmounts(value string) (map[string]decimal.Decimal, error) {
+	amounts := make(map[string]decimal.Decimal)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		currency, amount, ok := strings.Cut(pair, ":")
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !ok || len(currency) != 3 {
+			return nil, fmt.Errorf("must be a list of CURRENCY:AMOUNT, got %q", pair)
+		}
+
+		parsed, err := decimal.NewFromString(strings.TrimSpace(amount))
+		if err != nil || !parsed.IsPositive() {
+			return nil, fmt.Errorf("must have a positive amount for %s", currency)
+		}
+		amounts[currency] = parsed
+	}
+	return amounts, nil
+}
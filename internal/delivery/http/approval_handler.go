@@ -0,0 +1,132 @@
+package http
+
+import (
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/headers"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/params"
+	"github.com/labstack/echo/v4"
+)
+
+// ApprovalHandler serves the maker-checker queue, every action on it is an operator action
+type ApprovalHandler struct {
+	service service.TransactionService
+}
+
+func NewApprovalHandler(service service.TransactionService) *ApprovalHandler {
+	return &ApprovalHandler{service: service}
+}
+
+// CreateAdjustment answers 202, the adjustment is only posted once another admin approves it
+func (h *ApprovalHandler) CreateAdjustment(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+
+	idempotencyKey, err := headers.GetIdempotencyKey(c)
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	req := dto.AdjustmentRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	resp, statusCode, err := h.service.CreateAdjustment(c.Request().Context(), idempotencyKey, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(statusCode, resp)
+}
+
+func (h *ApprovalHandler) ListApprovals(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+
+	req := dto.ApprovalListRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	resp, err := h.service.ListApprovals(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, resp)
+}
+
+func (h *ApprovalHandler) GetApproval(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+
+	approvalID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	resp, err := h.service.GetApproval(c.Request().Context(), approvalID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, resp)
+}
+
+func (h *ApprovalHandler) ApproveRequest(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+
+	approvalID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	resp, err := h.service.ApproveRequest(c.Request().Context(), approvalID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, resp)
+}
+
+func (h *ApprovalHandler) RejectRequest(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+
+	approvalID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	req := dto.RejectApprovalRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	resp, err := h.service.RejectRequest(c.Request().Context(), approvalID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, resp)
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"strings"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/headers"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/signature"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderAPIKey = "X-API-Key"
+
+	principalContextKey = "principal"
+
+	// maxSignedBodyBytes bounds the body read to hash a signed request, it is read before
+	// the caller is authenticated
+	maxSignedBodyBytes = 1 << 20
+)
+
+// NewAuthMiddleware resolves the caller from an X-API-Key, an Authorization bearer token or
+// an HMAC request signature, see signature.Scheme.
+// With insecureHeader a request without credentials is trusted as is, so X-Wallet-ID alone
+// picks the wallet. That mode exists for local development only.
+func NewAuthMiddleware(auth service.AuthService, insecureHeader bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+
+			var principal service.Principal
+			var err error
+			apiKey := c.Request().Header.Get(HeaderAPIKey)
+			authorization := c.Request().Header.Get(echo.HeaderAuthorization)
+			switch {
+			case apiKey != "":
+				principal, err = auth.AuthenticateAPIKey(ctx, apiKey)
+			case strings.HasPrefix(authorization, signature.Scheme+" "):
+				principal, err = authenticateSignature(c, auth, authorization)
+			case authorization != "":
+				bearerToken, ok := strings.CutPrefix(authorization, "Bearer ")
+				if !ok {
+					return apperror.ErrUnauthorized.WithMessage("authorization must be a bearer token")
+				}
+				principal, err = auth.AuthenticateToken(ctx, strings.TrimSpace(bearerToken))
+			case insecureHeader:
+				principal = service.Principal{Unrestricted: true}
+			default:
+				return apperror.ErrUnauthorized
+			}
+			if err != nil {
+				return err
+			}
+
+			c.Set(principalContextKey, principal)
+			c.SetRequest(c.Request().WithContext(service.ContextWithPrincipal(ctx, principal)))
+			return next(c)
+		}
+	}
+}
+
+// authenticateSignature hashes the raw body and puts it back for the handler to bind
+func authenticateSignature(c echo.Context, auth service.AuthService, authorization string) (service.Principal, error) {
+	params, err := signature.ParseAuthorization(authorization)
+	if err != nil {
+		return service.Principal{}, apperror.ErrUnauthorized.WithMessage(err.Error())
+	}
+
+	request := c.Request()
+	body, err := io.ReadAll(nethttp.MaxBytesReader(c.Response(), request.Body, maxSignedBodyBytes))
+	if err != nil {
+		var maxBytesErr *nethttp.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return service.Principal{}, echo.ErrStatusRequestEntityTooLarge
+		}
+		return service.Principal{}, apperror.InvalidRequest(err)
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	return auth.AuthenticateSignature(request.Context(), service.SignedRequest{
+		Method:   request.Method,
+		Path:     request.URL.RequestURI(),
+		BodyHash: signature.BodyHash(body),
+		Params:   params,
+	})
+}
+
+func getPrincipal(c echo.Context) service.Principal {
+	principal, _ := c.Get(principalContextKey).(service.Principal)
+	return principal
+}
+
+// authorizedWalletID reads the wallet the request acts on from X-Wallet-ID, the caller
+// needs at least the role on it
+func authorizedWalletID(c echo.Context, role string) (int64, error) {
+	walletID, err := headers.GetWalletId(c)
+	if err != nil {
+		return 0, apperror.InvalidRequest(err)
+	}
+	if err := authorizeWallet(c, walletID, role); err != nil {
+		return 0, err
+	}
+	return walletID, nil
+}
+
+func authorizeWallet(c echo.Context, walletID int64, role string) error {
+	if !getPrincipal(c).CanAccessWallet(walletID, role) {
+		return apperror.ErrForbidden
+	}
+	return nil
+}
+
+// requireAdmin guards operator actions that are not scoped to a wallet of the caller. The
+// insecure header mode only opens the wallets, an admin still has to authenticate so the
+// maker and the checker of an approval are known.
+func requireAdmin(c echo.Context) error {
+	principal := getPrincipal(c)
+	if !principal.IsAdmin {
+		return apperror.ErrForbidden.WithMessage("only an admin can do this")
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/signature"
+	"github.com/labstack/echo/v4"
+)
+
+// fakeAuthService accepts every signed request and records the body hash it was given
+type fakeAuthService struct {
+	service.AuthService
+	called   bool
+	bodyHash string
+}
+
+func (s *fakeAuthService) AuthenticateSignature(ctx context.Context, req service.SignedRequest) (service.Principal, error) {
+	s.called = true
+	s.bodyHash = req.BodyHash
+	return service.Principal{ID: 1}, nil
+}
+
+const testSignedAuthorization = signature.Scheme + " Credential=client-1, Timestamp=1700000000, Nonce=abc, Signature=deadbeef"
+
+func serveSigned(auth service.AuthService, body string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.HTTPErrorHandler = NewErrorHandler(ErrorFormatProblem)
+	e.POST("/withdraw", handler, NewAuthMiddleware(auth, false))
+
+	req := httptest.NewRequest(nethttp.MethodPost, "/withdraw", strings.NewReader(body))
+	req.Header.Set(echo.HeaderAuthorization, testSignedAuthorization)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthMiddlewareSignedBodyIsReplayed(t *testing.T) {
+	auth := &fakeAuthService{}
+	body := `{"amount":"10"}`
+
+	var got string
+	rec := serveSigned(auth, body, func(c echo.Context) error {
+		raw, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		got = string(raw)
+		return c.NoContent(nethttp.StatusNoContent)
+	})
+
+	if rec.Code != nethttp.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, nethttp.StatusNoContent)
+	}
+	if got != body {
+		t.Errorf("handler body = %q, want %q", got, body)
+	}
+	if want := signature.BodyHash([]byte(body)); auth.bodyHash != want {
+		t.Errorf("body hash = %s, want %s", auth.bodyHash, want)
+	}
+}
+
+func TestAuthMiddlewareRejectsOversizedSignedBody(t *testing.T) {
+	auth := &fakeAuthService{}
+
+	rec := serveSigned(auth, strings.Repeat("a", maxSignedBodyBytes+1), func(c echo.Context) error {
+		return c.NoContent(nethttp.StatusNoContent)
+	})
+
+	if rec.Code != nethttp.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", rec.Code, nethttp.StatusRequestEntityTooLarge)
+	}
+	if auth.called {
+		t.Errorf("oversized request reached authentication")
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal service.Principal
+		want      int
+	}{
+		{name: "admin", principal: service.Principal{ID: 1, IsAdmin: true}, want: nethttp.StatusNoContent},
+		{name: "wallet owner", principal: service.Principal{ID: 2}, want: nethttp.StatusForbidden},
+		{name: "insecure header mode", principal: service.Principal{Unrestricted: true}, want: nethttp.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = NewErrorHandler(ErrorFormatProblem)
+			c := e.NewContext(httptest.NewRequest(nethttp.MethodPost, "/approvals/1/approve", nil), httptest.NewRecorder())
+			c.Set(principalContextKey, tt.principal)
+
+			err := requireAdmin(c)
+			if err == nil {
+				err = c.NoContent(nethttp.StatusNoContent)
+			}
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+			if got := c.Response().Status; got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
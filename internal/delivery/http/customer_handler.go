@@ -0,0 +1,103 @@
+package http
+
+import (
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/params"
+	"github.com/labstack/echo/v4"
+)
+
+type CustomerHandler struct {
+	service service.CustomerService
+}
+
+func NewCustomerHandler(service service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+// CreateCustomer is an operator action, principals are linked to customers by an admin
+func (h *CustomerHandler) CreateCustomer(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+
+	req := dto.CreateCustomerRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	customer, err := h.service.CreateCustomer(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(201, customer)
+}
+
+func (h *CustomerHandler) GetCustomer(c echo.Context) error {
+	customerID, err := authorizedCustomerID(c)
+	if err != nil {
+		return err
+	}
+
+	customer, err := h.service.GetCustomer(c.Request().Context(), customerID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, customer)
+}
+
+func (h *CustomerHandler) UpdateCustomer(c echo.Context) error {
+	customerID, err := authorizedCustomerID(c)
+	if err != nil {
+		return err
+	}
+
+	req := dto.UpdateCustomerRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	customer, err := h.service.UpdateCustomer(c.Request().Context(), customerID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, customer)
+}
+
+func (h *CustomerHandler) ListCustomerWallets(c echo.Context) error {
+	customerID, err := authorizedCustomerID(c)
+	if err != nil {
+		return err
+	}
+
+	wallets, err := h.service.ListCustomerWallets(c.Request().Context(), customerID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, wallets)
+}
+
+// authorizedCustomerID reads the customer from the path, the caller must act for it
+func authorizedCustomerID(c echo.Context) (int64, error) {
+	customerID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return 0, apperror.InvalidRequest(err)
+	}
+	if !getPrincipal(c).CanAccessCustomer(customerID) {
+		return 0, apperror.ErrForbidden.WithMessage("not allowed to access this customer")
+	}
+	return customerID, nil
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	nethttp "net/http"
+	"strings"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ErrorFormatProblem = "problem"
+	ErrorFormatLegacy  = "legacy"
+
+	MIMEApplicationProblemJSON = "application/problem+json"
+)
+
+// errorStatus maps domain error codes to HTTP status, unknown codes become 500
+var errorStatus = map[string]int{
+	apperror.CodeInvalidRequest:         nethttp.StatusBadRequest,
+	apperror.CodeUnauthorized:           nethttp.StatusUnauthorized,
+	apperror.CodeForbidden:              nethttp.StatusForbidden,
+	apperror.CodeInvalidAmount:          nethttp.StatusUnprocessableEntity,
+	apperror.CodeWalletNotFound:         nethttp.StatusNotFound,
+	apperror.CodeWalletNotEmpty:         nethttp.StatusConflict,
+	apperror.CodeCustomerNotFound:       nethttp.StatusNotFound,
+	apperror.CodeCustomerEmailTaken:     nethttp.StatusConflict,
+	apperror.CodeMemberNotFound:         nethttp.StatusNotFound,
+	apperror.CodeLastWalletOwner:        nethttp.StatusConflict,
+	apperror.CodeApprovalNotFound:       nethttp.StatusNotFound,
+	apperror.CodeApprovalNotPending:     nethttp.StatusConflict,
+	apperror.CodeSelfApproval:           nethttp.StatusForbidden,
+	apperror.CodeTransferNotFound:       nethttp.StatusNotFound,
+	apperror.CodeTransactionNotFound:    nethttp.StatusNotFound,
+	apperror.CodeNotReversible:          nethttp.StatusUnprocessableEntity,
+	apperror.CodeReversalExceedsAmount:  nethttp.StatusUnprocessableEntity,
+	apperror.CodeHoldNotFound:           nethttp.StatusNotFound,
+	apperror.CodeHoldNotActive:          nethttp.StatusConflict,
+	apperror.CodeInvalidStatusChange:    nethttp.StatusConflict,
+	apperror.CodeInsufficientFunds:      nethttp.StatusUnprocessableEntity,
+	apperror.CodeCurrencyMismatch:       nethttp.StatusUnprocessableEntity,
+	apperror.CodeRateUnavailable:        nethttp.StatusUnprocessableEntity,
+	apperror.CodeQuoteNotFound:          nethttp.StatusNotFound,
+	apperror.CodeQuoteExpired:           nethttp.StatusConflict,
+	apperror.CodeLimitExceeded:          nethttp.StatusUnprocessableEntity,
+	apperror.CodeSameWalletTransfer:     nethttp.StatusUnprocessableEntity,
+	apperror.CodeDuplicateRequest:       nethttp.StatusConflict,
+	apperror.CodeIdempotencyKeyMismatch: nethttp.StatusUnprocessableEntity,
+	apperror.CodeTransactionConflict:    nethttp.StatusServiceUnavailable,
+	apperror.CodeUnbalancedEntry:        nethttp.StatusInternalServerError,
+	apperror.CodeInternal:               nethttp.StatusInternalServerError,
+}
+
+// NewErrorHandler returns the central Echo error handler, handlers just return the error.
+// Responses are application/problem+json unless the legacy format is configured, in which
+// case clients can still opt in to problem+json through the Accept header.
+func NewErrorHandler(format string) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		appErr := toAppError(err)
+		status, ok := errorStatus[appErr.Code]
+		if !ok {
+			status = nethttp.StatusInternalServerError
+		}
+
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			status = httpErr.Code
+		}
+
+		if status >= nethttp.StatusInternalServerError {
+			log.Printf("error handling %s %s, err: %+v", c.Request().Method, c.Path(), err)
+		}
+
+		if errors.Is(err, apperror.ErrTransactionConflict) {
+			// Safe to retry with the same idempotency key
+			c.Response().Header().Set("Retry-After", "1")
+		}
+
+		if c.Request().Method == nethttp.MethodHead {
+			err = c.NoContent(status)
+		} else if format == ErrorFormatLegacy && !acceptsProblemJSON(c) {
+			err = c.JSON(status, dto.BaseError{
+				Code:    appErr.Code,
+				Message: appErr.Message,
+				Details: appErr.Details,
+			})
+		} else {
+			err = writeProblem(c, status, appErr)
+		}
+		if err != nil {
+			log.Printf("error writing error response, err: %+v", err)
+		}
+	}
+}
+
+// toAppError normalizes any error into a domain error
+func toAppError(err error) *apperror.Error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	// Errors raised by Echo itself, e.g. unknown route or malformed body
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		code := apperror.CodeInvalidRequest
+		if httpErr.Code != nethttp.StatusBadRequest {
+			code = strings.ToUpper(strings.ReplaceAll(nethttp.StatusText(httpErr.Code), " ", "_"))
+		}
+		return apperror.New(code, fmt.Sprint(httpErr.Message))
+	}
+
+	// Anything else is unexpected, don't leak its details to the client
+	return apperror.ErrInternal
+}
+
+func acceptsProblemJSON(c echo.Context) bool {
+	return strings.Contains(c.Request().Header.Get(echo.HeaderAccept), MIMEApplicationProblemJSON)
+}
+
+func writeProblem(c echo.Context, status int, appErr *apperror.Error) error {
+	problem := dto.ProblemDetails{
+		Type:     problemType(appErr.Code),
+		Title:    nethttp.StatusText(status),
+		Status:   status,
+		Detail:   appErr.Message,
+		Instance: c.Request().URL.Path,
+		Code:     appErr.Code,
+		Details:  appErr.Details,
+	}
+	for _, field := range appErr.Fields {
+		problem.Errors = append(problem.Errors, dto.ProblemFieldError{
+			Field:   field.Field,
+			Message: field.Message,
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	c.Response().WriteHeader(status)
+	return c.Echo().JSONSerializer.Serialize(c, problem, "")
+}
+
+// problemType turns a code like WALLET_NOT_FOUND into /problems/wallet-not-found
+func problemType(code string) string {
+	return "/problems/" + strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/headers"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/params"
+	"github.com/labstack/echo/v4"
+)
+
+type HoldHandler struct {
+	service service.TransactionService
+	maxTTL  time.Duration
+}
+
+func NewHoldHandler(service service.TransactionService, maxTTL time.Duration) *HoldHandler {
+	return &HoldHandler{service: service, maxTTL: maxTTL}
+}
+
+func (h *HoldHandler) CreateHold(c echo.Context) error {
+	walletID, err := authorizedWalletID(c, constant.WalletRoleSpender)
+	if err != nil {
+		return err
+	}
+
+	idempotencyKey, err := headers.GetIdempotencyKey(c)
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	req := dto.CreateHoldRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(h.maxTTL); err != nil {
+		return err
+	}
+
+	resp, statusCode, err := h.service.CreateHold(c.Request().Context(), idempotencyKey, walletID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(statusCode, resp)
+}
+
+func (h *HoldHandler) GetHold(c echo.Context) error {
+	walletID, err := authorizedWalletID(c, constant.WalletRoleViewer)
+	if err != nil {
+		return err
+	}
+
+	holdID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	resp, err := h.service.GetHold(c.Request().Context(), walletID, holdID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, resp)
+}
+
+func (h *HoldHandler) CaptureHold(c echo.Context) error {
+	walletID, err := authorizedWalletID(c, constant.WalletRoleSpender)
+	if err != nil {
+		return err
+	}
+
+	holdID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	idempotencyKey, err := headers.GetIdempotencyKey(c)
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	req := dto.CaptureHoldRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	resp, statusCode, err := h.service.CaptureHold(c.Request().Context(), idempotencyKey, walletID, holdID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(statusCode, resp)
+}
+
+func (h *HoldHandler) VoidHold(c echo.Context) error {
+	walletID, err := authorizedWalletID(c, constant.WalletRoleSpender)
+	if err != nil {
+		return err
+	}
+
+	holdID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	idempotencyKey, err := headers.GetIdempotencyKey(c)
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	resp, statusCode, err := h.service.VoidHold(c.Request().Context(), idempotencyKey, walletID, holdID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(statusCode, resp)
+}
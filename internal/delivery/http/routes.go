@@ -1,6 +1,7 @@
 package http
 
 import (
+	"github.com/krisnadwipayana07/restful-fintech/configs"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
 	"github.com/labstack/echo/v4"
 )
@@ -14,21 +15,92 @@ const (
 	DepositPath  = "/v1/deposit"
 	TransferPath = "/v1/transfer"
 
+	// Fee
+	FeePreviewPath = "/v1/fees/preview"
+
+	// Transfer
+	TransferDetailPath = "/v1/transfers/:id"
+	FXQuotesPath       = "/v1/fx/quotes"
+
+	// Transaction detail and reversal
+	TransactionDetailPath  = "/v1/transactions/:id"
+	TransactionReversePath = "/v1/transactions/:id/reverse"
+
+	// Hold
+	HoldsPath       = "/v1/holds"
+	HoldDetailPath  = "/v1/holds/:id"
+	HoldCapturePath = "/v1/holds/:id/capture"
+	HoldVoidPath    = "/v1/holds/:id/void"
+
 	// Wallet
+	WalletsPath       = "/v1/wallets"
+	WalletDetailPath  = "/v1/wallets/:id"
 	WalletHistoryPath = "/v1/wallet/history"
 	WalletBalancePath = "/v1/wallet/balance"
+
+	// Wallet members
+	WalletMembersPath      = "/v1/wallets/:id/members"
+	WalletMemberDetailPath = "/v1/wallets/:id/members/:customer_id"
+
+	// Approval
+	AdjustmentsPath     = "/v1/adjustments"
+	ApprovalsPath       = "/v1/approvals"
+	ApprovalDetailPath  = "/v1/approvals/:id"
+	ApprovalApprovePath = "/v1/approvals/:id/approve"
+	ApprovalRejectPath  = "/v1/approvals/:id/reject"
+
+	// Customer
+	CustomersPath       = "/v1/customers"
+	CustomerDetailPath  = "/v1/customers/:id"
+	CustomerWalletsPath = "/v1/customers/:id/wallets"
 )
 
-func InitHandler(e *echo.Echo, service service.Service) {
+func InitHandler(e *echo.Echo, service service.Service, config *configs.Config) {
+	e.HTTPErrorHandler = NewErrorHandler(config.ErrorFormat)
+
 	ph := NewPingHandler()
 	e.GET(PingPath, ph.Ping)
 
+	// Everything but ping needs an authenticated caller
+	api := e.Group("", NewAuthMiddleware(service.Auth, config.AuthInsecureHeader))
+
 	th := NewTransactionHandler(service.Transaction)
-	e.POST(WithdrawPath, th.Withdraw)
-	e.POST(DepositPath, th.Deposit)
-	e.POST(TransferPath, th.Transfer)
+	api.POST(WithdrawPath, th.Withdraw)
+	api.POST(DepositPath, th.Deposit)
+	api.POST(TransferPath, th.Transfer)
+	api.GET(FeePreviewPath, th.PreviewFee)
+	api.GET(TransferDetailPath, th.GetTransfer)
+	api.POST(FXQuotesPath, th.CreateFXQuote)
+	api.GET(TransactionDetailPath, th.GetTransaction)
+	api.POST(TransactionReversePath, th.ReverseTransaction)
+
+	hh := NewHoldHandler(service.Transaction, config.HoldMaxTTL)
+	api.POST(HoldsPath, hh.CreateHold)
+	api.GET(HoldDetailPath, hh.GetHold)
+	api.POST(HoldCapturePath, hh.CaptureHold)
+	api.POST(HoldVoidPath, hh.VoidHold)
+
+	ah := NewApprovalHandler(service.Transaction)
+	api.POST(AdjustmentsPath, ah.CreateAdjustment)
+	api.GET(ApprovalsPath, ah.ListApprovals)
+	api.GET(ApprovalDetailPath, ah.GetApproval)
+	api.POST(ApprovalApprovePath, ah.ApproveRequest)
+	api.POST(ApprovalRejectPath, ah.RejectRequest)
 
 	wh := NewWalletHandler(service.Wallet)
-	e.GET(WalletHistoryPath, wh.WalletHistory)
-	e.GET(WalletBalancePath, wh.WalletBalance)
+	api.POST(WalletsPath, wh.CreateWallet)
+	api.GET(WalletDetailPath, wh.GetWallet)
+	api.PATCH(WalletDetailPath, wh.RenameWallet)
+	api.DELETE(WalletDetailPath, wh.CloseWallet)
+	api.GET(WalletHistoryPath, wh.WalletHistory)
+	api.GET(WalletBalancePath, wh.WalletBalance)
+	api.GET(WalletMembersPath, wh.ListMembers)
+	api.PUT(WalletMemberDetailPath, wh.SaveMember)
+	api.DELETE(WalletMemberDetailPath, wh.RemoveMember)
+
+	ch := NewCustomerHandler(service.Customer)
+	api.POST(CustomersPath, ch.CreateCustomer)
+	api.GET(CustomerDetailPath, ch.GetCustomer)
+	api.PATCH(CustomerDetailPath, ch.UpdateCustomer)
+	api.GET(CustomerWalletsPath, ch.ListCustomerWallets)
 }
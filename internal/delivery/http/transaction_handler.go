@@ -1,9 +1,12 @@
 package http
 
 import (
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
 	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
 	"github.com/krisnadwipayana07/restful-fintech/pkg/headers"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/params"
 	"github.com/labstack/echo/v4"
 )
 
@@ -16,97 +19,202 @@ func NewTransactionHandler(service service.TransactionService) *TransactionHandl
 }
 
 func (h *TransactionHandler) Withdraw(c echo.Context) error {
-	walletID, err := headers.GetWalletId(c)
+	walletID, err := authorizedWalletID(c, constant.WalletRoleSpender)
 	if err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	idempotencyKey, err := headers.GetIdempotencyKey(c)
 	if err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return apperror.InvalidRequest(err)
 	}
 
 	transaction := dto.AmountRequest{}
 	if err := c.Bind(&transaction); err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
-	resp, err := h.service.Withdraw(c.Request().Context(), idempotencyKey, walletID, transaction)
+	if err := transaction.Validate(); err != nil {
+		return err
+	}
+
+	resp, statusCode, err := h.service.Withdraw(c.Request().Context(), idempotencyKey, walletID, transaction)
 	if err != nil {
-		return c.JSON(500, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(200, resp)
+	return c.JSON(statusCode, resp)
 }
 
 func (h *TransactionHandler) Deposit(c echo.Context) error {
-	walletID, err := headers.GetWalletId(c)
+	walletID, err := authorizedWalletID(c, constant.WalletRoleSpender)
 	if err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	idempotencyKey, err := headers.GetIdempotencyKey(c)
 	if err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return apperror.InvalidRequest(err)
 	}
 
 	transaction := dto.AmountRequest{}
 	if err := c.Bind(&transaction); err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
+	}
+
+	if err := transaction.Validate(); err != nil {
+		return err
 	}
 
-	resp, err := h.service.Deposit(c.Request().Context(), idempotencyKey, walletID, transaction)
+	resp, statusCode, err := h.service.Deposit(c.Request().Context(), idempotencyKey, walletID, transaction)
 	if err != nil {
-		return c.JSON(500, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(200, resp)
+	return c.JSON(statusCode, resp)
 }
 
 func (h *TransactionHandler) Transfer(c echo.Context) error {
-	walletID, err := headers.GetWalletId(c)
+	walletID, err := authorizedWalletID(c, constant.WalletRoleSpender)
 	if err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	idempotencyKey, err := headers.GetIdempotencyKey(c)
 	if err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return apperror.InvalidRequest(err)
 	}
 
 	transaction := dto.TransferRequest{}
 	if err := c.Bind(&transaction); err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
+	}
+
+	if err := transaction.Validate(); err != nil {
+		return err
+	}
+
+	resp, statusCode, err := h.service.Transfer(c.Request().Context(), idempotencyKey, walletID, transaction)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(statusCode, resp)
+}
+
+func (h *TransactionHandler) CreateFXQuote(c echo.Context) error {
+	walletID, err := authorizedWalletID(c, constant.WalletRoleSpender)
+	if err != nil {
+		return err
+	}
+
+	req := dto.FXQuoteRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	resp, err := h.service.CreateFXQuote(c.Request().Context(), walletID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(201, resp)
+}
+
+func (h *TransactionHandler) PreviewFee(c echo.Context) error {
+	walletID, err := authorizedWalletID(c, constant.WalletRoleViewer)
+	if err != nil {
+		return err
+	}
+
+	req := dto.FeePreviewRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	resp, err := h.service.PreviewFee(c.Request().Context(), walletID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, resp)
+}
+
+func (h *TransactionHandler) GetTransfer(c echo.Context) error {
+	walletID, err := authorizedWalletID(c, constant.WalletRoleViewer)
+	if err != nil {
+		return err
+	}
+
+	transferID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	resp, err := h.service.GetTransfer(c.Request().Context(), walletID, transferID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, resp)
+}
+
+func (h *TransactionHandler) GetTransaction(c echo.Context) error {
+	walletID, err := authorizedWalletID(c, constant.WalletRoleViewer)
+	if err != nil {
+		return err
+	}
+
+	transactionID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
 	}
 
-	resp, err := h.service.Transfer(c.Request().Context(), idempotencyKey, walletID, transaction)
+	resp, err := h.service.GetTransaction(c.Request().Context(), walletID, transactionID)
 	if err != nil {
-		return c.JSON(500, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(200, resp)
 }
+
+// ReverseTransaction is an operator action, a customer can't undo its own transfers
+func (h *TransactionHandler) ReverseTransaction(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+
+	transactionID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	idempotencyKey, err := headers.GetIdempotencyKey(c)
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	req := dto.ReverseRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	resp, statusCode, err := h.service.ReverseTransaction(c.Request().Context(), idempotencyKey, transactionID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(statusCode, resp)
+}
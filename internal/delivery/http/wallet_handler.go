@@ -1,9 +1,11 @@
 package http
 
 import (
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
 	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
-	"github.com/krisnadwipayana07/restful-fintech/pkg/headers"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/params"
 	"github.com/labstack/echo/v4"
 )
 
@@ -15,38 +17,191 @@ func NewWalletHandler(service service.WalletService) *WalletHandler {
 	return &WalletHandler{service: service}
 }
 
+func (h *WalletHandler) CreateWallet(c echo.Context) error {
+	req := dto.CreateWalletRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	wallet, err := h.service.CreateWallet(c.Request().Context(), getPrincipal(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(201, wallet)
+}
+
+func (h *WalletHandler) GetWallet(c echo.Context) error {
+	walletID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	if err := authorizeWallet(c, walletID, constant.WalletRoleViewer); err != nil {
+		return err
+	}
+
+	wallet, err := h.service.GetWallet(c.Request().Context(), walletID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, wallet)
+}
+
+func (h *WalletHandler) RenameWallet(c echo.Context) error {
+	walletID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	if err := authorizeWallet(c, walletID, constant.WalletRoleOwner); err != nil {
+		return err
+	}
+
+	req := dto.RenameWalletRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	wallet, err := h.service.RenameWallet(c.Request().Context(), walletID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, wallet)
+}
+
+func (h *WalletHandler) CloseWallet(c echo.Context) error {
+	walletID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	if err := authorizeWallet(c, walletID, constant.WalletRoleOwner); err != nil {
+		return err
+	}
+
+	if err := h.service.CloseWallet(c.Request().Context(), walletID); err != nil {
+		return err
+	}
+
+	return c.NoContent(204)
+}
+
 func (h *WalletHandler) WalletHistory(c echo.Context) error {
-	walletID, err := headers.GetWalletId(c)
+	walletID, err := authorizedWalletID(c, constant.WalletRoleViewer)
 	if err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
-	transactionList, err := h.service.WalletHistory(c.Request().Context(), walletID)
+	req := dto.WalletHistoryRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	transactionList, err := h.service.WalletHistory(c.Request().Context(), walletID, req)
 	if err != nil {
-		return c.JSON(500, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(200, transactionList)
 }
 
 func (h *WalletHandler) WalletBalance(c echo.Context) error {
-	walletID, err := headers.GetWalletId(c)
+	walletID, err := authorizedWalletID(c, constant.WalletRoleViewer)
 	if err != nil {
-		return c.JSON(400, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	balance, err := h.service.WalletBalance(c.Request().Context(), walletID)
 	if err != nil {
-		return c.JSON(500, dto.BaseError{
-			Message: err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(200, balance)
 }
+
+func (h *WalletHandler) ListMembers(c echo.Context) error {
+	walletID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	if err := authorizeWallet(c, walletID, constant.WalletRoleViewer); err != nil {
+		return err
+	}
+
+	members, err := h.service.ListMembers(c.Request().Context(), walletID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, members)
+}
+
+func (h *WalletHandler) SaveMember(c echo.Context) error {
+	walletID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	customerID, err := params.GetPathID(c, "customer_id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	if err := authorizeWallet(c, walletID, constant.WalletRoleOwner); err != nil {
+		return err
+	}
+
+	req := dto.SaveWalletMemberRequest{}
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	member, err := h.service.SaveMember(c.Request().Context(), walletID, customerID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(200, member)
+}
+
+func (h *WalletHandler) RemoveMember(c echo.Context) error {
+	walletID, err := params.GetPathID(c, "id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	customerID, err := params.GetPathID(c, "customer_id")
+	if err != nil {
+		return apperror.InvalidRequest(err)
+	}
+
+	if err := authorizeWallet(c, walletID, constant.WalletRoleOwner); err != nil {
+		return err
+	}
+
+	if err := h.service.RemoveMember(c.Request().Context(), walletID, customerID); err != nil {
+		return err
+	}
+
+	return c.NoContent(204)
+}
@@ -0,0 +1,70 @@
+package job
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/configs"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
+)
+
+// Start runs the background jobs until ctx is cancelled
+func Start(ctx context.Context, service service.Service, config *configs.Config) {
+	go runEvery(ctx, "hold expiry", config.HoldExpiryInterval, func(ctx context.Context) error {
+		expired, err := service.Transaction.ExpireHolds(ctx)
+		if expired > 0 {
+			log.Printf("released %d expired holds", expired)
+		}
+		return err
+	})
+
+	go runEvery(ctx, "deposit clearing", config.DepositClearingInterval, func(ctx context.Context) error {
+		cleared, err := service.Transaction.ClearDeposits(ctx)
+		if cleared > 0 {
+			log.Printf("cleared %d deposits", cleared)
+		}
+		return err
+	})
+
+	go runEvery(ctx, "idempotency cleanup", config.IdempotencyCleanupInterval, func(ctx context.Context) error {
+		purged, err := service.Transaction.PurgeIdempotencyKeys(ctx)
+		if purged > 0 {
+			log.Printf("purged %d expired idempotency keys", purged)
+		}
+		return err
+	})
+
+	go runEvery(ctx, "balance reconciliation", config.BalanceReconciliationInterval, func(ctx context.Context) error {
+		drifted, err := service.Transaction.ReconcileBalances(ctx)
+		if drifted > 0 {
+			log.Printf("found %d wallets whose balance differs from their postings", drifted)
+		}
+		return err
+	})
+
+	go runEvery(ctx, "approval expiry", config.ApprovalExpiryInterval, func(ctx context.Context) error {
+		expired, err := service.Transaction.ExpireApprovals(ctx)
+		if expired > 0 {
+			log.Printf("expired %d approval requests", expired)
+		}
+		return err
+	})
+}
+
+// runEvery calls fn on every tick, a failed run is logged and retried on the next tick
+func runEvery(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				log.Printf("error running %s job, err: %+v", name, err)
+			}
+		}
+	}
+}
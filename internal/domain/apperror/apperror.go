@@ -0,0 +1,129 @@
+package apperror
+
+import "errors"
+
+const (
+	CodeInvalidRequest         = "INVALID_REQUEST"
+	CodeUnauthorized           = "UNAUTHORIZED"
+	CodeForbidden              = "FORBIDDEN"
+	CodeInvalidAmount          = "INVALID_AMOUNT"
+	CodeWalletNotFound         = "WALLET_NOT_FOUND"
+	CodeWalletNotEmpty         = "WALLET_NOT_EMPTY"
+	CodeCustomerNotFound       = "CUSTOMER_NOT_FOUND"
+	CodeCustomerEmailTaken     = "CUSTOMER_EMAIL_TAKEN"
+	CodeMemberNotFound         = "MEMBER_NOT_FOUND"
+	CodeLastWalletOwner        = "LAST_WALLET_OWNER"
+	CodeApprovalNotFound       = "APPROVAL_NOT_FOUND"
+	CodeApprovalNotPending     = "APPROVAL_NOT_PENDING"
+	CodeSelfApproval           = "SELF_APPROVAL"
+	CodeTransferNotFound       = "TRANSFER_NOT_FOUND"
+	CodeTransactionNotFound    = "TRANSACTION_NOT_FOUND"
+	CodeNotReversible          = "NOT_REVERSIBLE"
+	CodeReversalExceedsAmount  = "REVERSAL_EXCEEDS_AMOUNT"
+	CodeHoldNotFound           = "HOLD_NOT_FOUND"
+	CodeHoldNotActive          = "HOLD_NOT_ACTIVE"
+	CodeInvalidStatusChange    = "INVALID_STATUS_TRANSITION"
+	CodeInsufficientFunds      = "INSUFFICIENT_FUNDS"
+	CodeSameWalletTransfer     = "SAME_WALLET_TRANSFER"
+	CodeCurrencyMismatch       = "CURRENCY_MISMATCH"
+	CodeRateUnavailable        = "RATE_UNAVAILABLE"
+	CodeQuoteNotFound          = "QUOTE_NOT_FOUND"
+	CodeQuoteExpired           = "QUOTE_EXPIRED"
+	CodeLimitExceeded          = "LIMIT_EXCEEDED"
+	CodeDuplicateRequest       = "DUPLICATE_REQUEST"
+	CodeIdempotencyKeyMismatch = "IDEMPOTENCY_KEY_MISMATCH"
+	CodeTransactionConflict    = "TRANSACTION_CONFLICT"
+	CodeUnbalancedEntry        = "UNBALANCED_ENTRY"
+	CodeInternal               = "INTERNAL_ERROR"
+)
+
+// Error is a domain error with a stable machine readable code.
+// Two errors with the same code match with errors.Is, whatever the message.
+// Details carries machine readable context, like the headroom left under a limit.
+type Error struct {
+	Code    string
+	Message string
+	Fields  []FieldError
+	Details map[string]interface{}
+}
+
+// FieldError points a validation failure at a single request field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func New(code string, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !errors.As(target, &t) {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+// WithMessage keeps the code but replaces the message shown to the client
+func (e *Error) WithMessage(message string) *Error {
+	return &Error{Code: e.Code, Message: message}
+}
+
+// WithDetails keeps the code and message and attaches details for the client
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	return &Error{Code: e.Code, Message: e.Message, Details: details}
+}
+
+var (
+	ErrInvalidRequest         = New(CodeInvalidRequest, "invalid request")
+	ErrUnauthorized           = New(CodeUnauthorized, "authentication required")
+	ErrForbidden              = New(CodeForbidden, "not allowed to access this wallet")
+	ErrInvalidAmount          = New(CodeInvalidAmount, "amount must be greater than 0")
+	ErrWalletNotFound         = New(CodeWalletNotFound, "wallet not found")
+	ErrWalletNotEmpty         = New(CodeWalletNotEmpty, "wallet balance must be zero before closing")
+	ErrCustomerNotFound       = New(CodeCustomerNotFound, "customer not found")
+	ErrCustomerEmailTaken     = New(CodeCustomerEmailTaken, "another customer already uses this email")
+	ErrMemberNotFound         = New(CodeMemberNotFound, "wallet member not found")
+	ErrLastWalletOwner        = New(CodeLastWalletOwner, "a wallet must keep at least one owner")
+	ErrApprovalNotFound       = New(CodeApprovalNotFound, "approval request not found")
+	ErrApprovalNotPending     = New(CodeApprovalNotPending, "approval request was already decided")
+	ErrSelfApproval           = New(CodeSelfApproval, "the maker of a request can't decide on it")
+	ErrTransferNotFound       = New(CodeTransferNotFound, "transfer not found")
+	ErrTransactionNotFound    = New(CodeTransactionNotFound, "transaction not found")
+	ErrNotReversible          = New(CodeNotReversible, "a reversal can't be reversed")
+	ErrReversalExceedsAmount  = New(CodeReversalExceedsAmount, "reversal exceeds the amount left to reverse")
+	ErrHoldNotFound           = New(CodeHoldNotFound, "hold not found")
+	ErrHoldNotActive          = New(CodeHoldNotActive, "hold is no longer active")
+	ErrInvalidStatusChange    = New(CodeInvalidStatusChange, "transaction can't move to that status")
+	ErrInsufficientFunds      = New(CodeInsufficientFunds, "insufficient balance")
+	ErrSameWalletTransfer     = New(CodeSameWalletTransfer, "cannot transfer to the same wallet")
+	ErrCurrencyMismatch       = New(CodeCurrencyMismatch, "wallets hold different currencies, transfer with a quote_id")
+	ErrRateUnavailable        = New(CodeRateUnavailable, "no exchange rate for the currency pair")
+	ErrQuoteNotFound          = New(CodeQuoteNotFound, "quote not found")
+	ErrQuoteExpired           = New(CodeQuoteExpired, "quote has expired, request a new one")
+	ErrLimitExceeded          = New(CodeLimitExceeded, "transaction limit exceeded")
+	ErrDuplicateRequest       = New(CodeDuplicateRequest, "a request with this idempotency key is still in progress")
+	ErrIdempotencyKeyMismatch = New(CodeIdempotencyKeyMismatch, "idempotency key was already used with a different request")
+	ErrTransactionConflict    = New(CodeTransactionConflict, "transaction conflict, please retry the request")
+	ErrUnbalancedEntry        = New(CodeUnbalancedEntry, "journal entry postings do not balance")
+	ErrInternal               = New(CodeInternal, "internal server error")
+)
+
+// InvalidRequest wraps a parsing or validation failure as ErrInvalidRequest
+func InvalidRequest(err error) *Error {
+	return ErrInvalidRequest.WithMessage(err.Error())
+}
+
+// Validation reports every invalid request field at once
+func Validation(fields ...FieldError) *Error {
+	return &Error{
+		Code:    CodeInvalidRequest,
+		Message: "request validation failed",
+		Fields:  fields,
+	}
+}
@@ -0,0 +1,25 @@
+package constant
+
+// An approval request waits pending until a second principal approves or rejects it, or
+// until it expires. Only a pending request can be decided.
+const (
+	ApprovalStatusPending  = "pending"
+	ApprovalStatusApproved = "approved"
+	ApprovalStatusRejected = "rejected"
+	ApprovalStatusExpired  = "expired"
+)
+
+// Operations that go through an approval before they are posted
+const (
+	ApprovalOperationTransfer   = "transfer"
+	ApprovalOperationAdjustment = "adjustment"
+	ApprovalOperationReversal   = "reversal"
+)
+
+// Actions recorded in the approval audit log
+const (
+	ApprovalActionRequested = "requested"
+	ApprovalActionApproved  = "approved"
+	ApprovalActionRejected  = "rejected"
+	ApprovalActionExpired   = "expired"
+)
@@ -0,0 +1,28 @@
+package constant
+
+// DefaultCurrency is used for wallets created without a currency and for wallets from
+// before currencies existed
+const DefaultCurrency = "USD"
+
+// currencyMinorUnits is the number of decimals of each supported ISO 4217 currency
+var currencyMinorUnits = map[string]int32{
+	"AUD": 2,
+	"BHD": 3,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"IDR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MYR": 2,
+	"SGD": 2,
+	"USD": 2,
+}
+
+// CurrencyMinorUnits returns how many decimals an amount in the currency may have,
+// false when the currency is not supported
+func CurrencyMinorUnits(code string) (int32, bool) {
+	units, ok := currencyMinorUnits[code]
+	return units, ok
+}
@@ -0,0 +1,8 @@
+package constant
+
+// How a fee schedule prices an operation, see model.FeeSchedule
+const (
+	FeeKindFlat       = "flat"
+	FeeKindPercentage = "percentage"
+	FeeKindTiered     = "tiered"
+)
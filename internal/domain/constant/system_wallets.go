@@ -0,0 +1,14 @@
+package constant
+
+// System wallets are the counter accounts of the ledger, they are never exposed to customers
+const (
+	SystemWalletCashIn  = "CASH_IN"
+	SystemWalletCashOut = "CASH_OUT"
+	SystemWalletFees    = "FEES"
+	// Position account of currency conversions, one per currency
+	SystemWalletFX = "FX"
+	// Counter account of entries migrated from before the ledger existed
+	SystemWalletSuspense = "SUSPENSE"
+	// Counter account of manual balance adjustments
+	SystemWalletAdjustments = "ADJUSTMENTS"
+)
@@ -0,0 +1,25 @@
+package constant
+
+// A transaction starts pending or completed. Pending ends completed or failed, completed
+// can only go on to reversed once its journal entry is fully reversed.
+const (
+	TransactionStatusPending   = "pending"
+	TransactionStatusCompleted = "completed"
+	TransactionStatusFailed    = "failed"
+	TransactionStatusReversed  = "reversed"
+)
+
+var transactionStatusTransitions = map[string][]string{
+	TransactionStatusPending:   {TransactionStatusCompleted, TransactionStatusFailed},
+	TransactionStatusCompleted: {TransactionStatusReversed},
+}
+
+// CanTransitionTransactionStatus reports whether a transaction may move from one status to another
+func CanTransitionTransactionStatus(from string, to string) bool {
+	for _, next := range transactionStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
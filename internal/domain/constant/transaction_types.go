@@ -1,7 +1,18 @@
 package constant
 
 const (
-	TransactionTypeWithdraw int16 = 1
-	TransactionTypeDeposit  int16 = 2
-	TransactionTypeTransfer int16 = 3
+	TransactionTypeWithdraw    int16 = 1
+	TransactionTypeDeposit     int16 = 2
+	TransactionTypeTransfer    int16 = 3
+	TransactionTypeReversal    int16 = 4
+	TransactionTypeHoldCapture int16 = 5
+	TransactionTypeAdjustment  int16 = 6
 )
+
+func IsTransactionType(t int16) bool {
+	switch t {
+	case TransactionTypeWithdraw, TransactionTypeDeposit, TransactionTypeTransfer, TransactionTypeReversal, TransactionTypeHoldCapture, TransactionTypeAdjustment:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package constant
+
+// Roles of the members of a shared wallet, each one can do everything the ones below can.
+// A viewer only reads, a spender also moves money up to its spend limit, an owner also
+// manages the wallet and its members.
+const (
+	WalletRoleOwner   = "owner"
+	WalletRoleSpender = "spender"
+	WalletRoleViewer  = "viewer"
+)
+
+var walletRoleRank = map[string]int{
+	WalletRoleViewer:  1,
+	WalletRoleSpender: 2,
+	WalletRoleOwner:   3,
+}
+
+func IsWalletRole(role string) bool {
+	_, ok := walletRoleRank[role]
+	return ok
+}
+
+// WalletRoleAllows tells whether a member with role may act where required is needed
+func WalletRoleAllows(role string, required string) bool {
+	return walletRoleRank[role] >= walletRoleRank[required] && walletRoleRank[required] > 0
+}
@@ -0,0 +1,4 @@
+package constant
+
+// Wallets start in the standard tier, other tiers only exist through their limits
+const WalletTierStandard = "standard"
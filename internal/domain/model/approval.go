@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ApprovalRequest holds an operation until a checker other than its maker approves it.
+// Payload is the JSON request of the operation, it is posted as is on approval. The maker
+// customer is kept so the posting is initiated by the maker, not by the checker.
+type ApprovalRequest struct {
+	ID              int64           `gorm:"column:id"`
+	Operation       string          `gorm:"column:apr_operation"`
+	WalletID        int64           `gorm:"column:wallet_id"`
+	Amount          decimal.Decimal `gorm:"column:apr_amount"`
+	Payload         string          `gorm:"column:apr_payload"`
+	Status          string          `gorm:"column:apr_status"`
+	MakerID         int64           `gorm:"column:apr_maker_principal_id"`
+	MakerCustomerID *int64          `gorm:"column:apr_maker_customer_id"`
+	CheckerID       *int64          `gorm:"column:apr_checker_principal_id"`
+	DecisionReason  *string         `gorm:"column:apr_decision_reason"`
+	TransactionID   *int64          `gorm:"column:apr_transaction_id"`
+	ExpiresAt       time.Time       `gorm:"column:expires_at"`
+	DecidedAt       *time.Time      `gorm:"column:decided_at"`
+	CreatedAt       time.Time       `gorm:"column:created_at"`
+}
+
+func (ApprovalRequest) TableName() string {
+	return "approval_request_table"
+}
+
+// ApprovalAudit is one entry of the append-only log of approval decisions, PrincipalID is
+// nil when the expiry job made the decision
+type ApprovalAudit struct {
+	ID                int64     `gorm:"column:id"`
+	ApprovalRequestID int64     `gorm:"column:approval_request_id"`
+	Action            string    `gorm:"column:aud_action"`
+	PrincipalID       *int64    `gorm:"column:aud_principal_id"`
+	Remarks           string    `gorm:"column:aud_remarks"`
+	CreatedAt         time.Time `gorm:"column:created_at"`
+}
+
+func (ApprovalAudit) TableName() string {
+	return "approval_audit_table"
+}
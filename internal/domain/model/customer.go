@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Customer is the person or business behind wallets, one customer owns many wallets
+type Customer struct {
+	ID        int64      `gorm:"column:id"`
+	Name      string     `gorm:"column:cst_name"`
+	Email     string     `gorm:"column:cst_email"`
+	Phone     *string    `gorm:"column:cst_phone"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at"`
+	DeletedAt *time.Time `gorm:"column:deleted_at"`
+}
+
+func (Customer) TableName() string {
+	return "customer_table"
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DepositClearing tracks a deposit until it clears. Amount is what is still uncleared, a
+// reversal during the clearing period takes it down. ClearedAt is set once released.
+type DepositClearing struct {
+	ID             int64           `gorm:"column:id"`
+	WalletID       int64           `gorm:"column:wallet_id"`
+	JournalEntryID int64           `gorm:"column:journal_entry_id"`
+	Amount         decimal.Decimal `gorm:"column:dc_amount"`
+	ClearsAt       time.Time       `gorm:"column:clears_at"`
+	ClearedAt      *time.Time      `gorm:"column:cleared_at"`
+	CreatedAt      time.Time       `gorm:"column:created_at"`
+}
+
+func (DepositClearing) TableName() string {
+	return "deposit_clearing_table"
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate is the mid-market rate of one unit of BaseCurrency in QuoteCurrency
+type ExchangeRate struct {
+	BaseCurrency  string          `gorm:"column:er_base_currency" json:"base"`
+	QuoteCurrency string          `gorm:"column:er_quote_currency" json:"quote"`
+	Rate          decimal.Decimal `gorm:"column:er_rate" json:"rate"`
+	UpdatedAt     time.Time       `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rate_table"
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FeeSchedule prices one transaction type in one currency. A flat fee is FlatAmount, a
+// percentage fee is the amount times Percentage and a tiered fee uses the first tier the
+// amount fits in. The fee is then kept between MinFee and MaxFee when they are set.
+type FeeSchedule struct {
+	ID              int64            `gorm:"column:id"`
+	TransactionType int16            `gorm:"column:fs_transaction_type"`
+	Currency        string           `gorm:"column:fs_currency"`
+	Kind            string           `gorm:"column:fs_kind"`
+	FlatAmount      decimal.Decimal  `gorm:"column:fs_flat_amount"`
+	Percentage      decimal.Decimal  `gorm:"column:fs_percentage"`
+	MinFee          *decimal.Decimal `gorm:"column:fs_min_fee"`
+	MaxFee          *decimal.Decimal `gorm:"column:fs_max_fee"`
+	Tiers           FeeTiers         `gorm:"column:fs_tiers"`
+	IsActive        bool             `gorm:"column:fs_is_active"`
+	CreatedAt       time.Time        `gorm:"column:created_at"`
+	UpdatedAt       time.Time        `gorm:"column:updated_at"`
+}
+
+// FeeTier covers amounts up to UpTo, the last tier leaves UpTo empty to cover the rest
+type FeeTier struct {
+	UpTo       *decimal.Decimal `json:"up_to,omitempty"`
+	Flat       decimal.Decimal  `json:"flat"`
+	Percentage decimal.Decimal  `json:"percentage"`
+}
+
+// FeeTiers is stored as a JSONB array ordered by UpTo
+type FeeTiers []FeeTier
+
+func (t FeeTiers) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+func (t *FeeTiers) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return errors.New("unsupported fee tiers value")
+	}
+}
+
+func (FeeSchedule) TableName() string {
+	return "fee_schedule_table"
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FXQuote locks a conversion between two wallets until ExpiresAt. Rate is the mid rate
+// with Spread taken off, TargetAmount is SourceAmount at Rate rounded down to the target
+// currency. JournalEntryID is set once a transfer used the quote. ApprovalRequestID is set
+// when a transfer waiting for approval holds the quote, its expiry then follows the approval.
+type FXQuote struct {
+	ID                int64           `gorm:"column:id"`
+	WalletID          int64           `gorm:"column:wallet_id"`
+	ReceiverWalletID  int64           `gorm:"column:receiver_wallet_id"`
+	SourceCurrency    string          `gorm:"column:fxq_source_currency"`
+	TargetCurrency    string          `gorm:"column:fxq_target_currency"`
+	SourceAmount      decimal.Decimal `gorm:"column:fxq_source_amount"`
+	TargetAmount      decimal.Decimal `gorm:"column:fxq_target_amount"`
+	MidRate           decimal.Decimal `gorm:"column:fxq_mid_rate"`
+	Spread            decimal.Decimal `gorm:"column:fxq_spread"`
+	Rate              decimal.Decimal `gorm:"column:fxq_rate"`
+	JournalEntryID    *int64          `gorm:"column:fxq_journal_entry_id"`
+	ApprovalRequestID *int64          `gorm:"column:fxq_approval_request_id"`
+	ExpiresAt         time.Time       `gorm:"column:expires_at"`
+	CreatedAt         time.Time       `gorm:"column:created_at"`
+}
+
+func (FXQuote) TableName() string {
+	return "fx_quote_table"
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Hold reserves part of a wallet balance until it is captured, voided or expires.
+// While active its amount is counted in Wallet.HeldBalance. The initiator is the principal,
+// and the member customer it acted for, that placed the hold.
+type Hold struct {
+	ID                    int64           `gorm:"column:id"`
+	WalletID              int64           `gorm:"column:wallet_id"`
+	Amount                decimal.Decimal `gorm:"column:hold_amount"`
+	CapturedAmount        decimal.Decimal `gorm:"column:hold_captured_amount"`
+	Status                string          `gorm:"column:hold_status"`
+	Remarks               string          `gorm:"column:hold_remarks"`
+	CaptureJournalEntryID *int64          `gorm:"column:hold_capture_journal_entry_id"`
+	InitiatorID           *int64          `gorm:"column:hold_initiator_principal_id"`
+	InitiatorCustomerID   *int64          `gorm:"column:hold_initiator_customer_id"`
+	ExpiresAt             time.Time       `gorm:"column:expires_at"`
+	CreatedAt             time.Time       `gorm:"column:created_at"`
+	UpdatedAt             time.Time       `gorm:"column:updated_at"`
+}
+
+func (Hold) TableName() string {
+	return "hold_table"
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	IdempotencyStatusInProgress = "in_progress"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyRecord is what gets stored against an idempotency key, a replay responds
+// with the stored StatusCode and Body
+type IdempotencyRecord struct {
+	Key         string          `gorm:"column:idem_key;primaryKey" json:"-"`
+	Status      string          `gorm:"column:idem_status" json:"status"`
+	Fingerprint string          `gorm:"column:idem_fingerprint" json:"fingerprint"`
+	StatusCode  int             `gorm:"column:idem_status_code" json:"status_code"`
+	Body        json.RawMessage `gorm:"column:idem_body" json:"body,omitempty"`
+	CreatedAt   time.Time       `gorm:"column:created_at" json:"-"`
+	ExpiresAt   time.Time       `gorm:"column:expires_at" json:"-"`
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_table"
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// JournalEntry groups the transaction rows (postings) of one operation,
+// the signed values of its postings always sum to zero.
+// A transfer is identified by its journal entry ID, shared by both legs.
+// A cross-currency transfer records the quote it used, Amount is then in the source currency.
+// FeeAmount is charged on top of Amount.
+type JournalEntry struct {
+	ID             int64            `gorm:"column:id"`
+	Type           int16            `gorm:"column:je_type"`
+	Amount         decimal.Decimal  `gorm:"column:je_amount"`
+	ReversedAmount decimal.Decimal  `gorm:"column:je_reversed_amount"`
+	ReversalOfID   *int64           `gorm:"column:je_reversal_of_id"`
+	Remarks        string           `gorm:"column:je_remarks"`
+	FeeAmount      decimal.Decimal  `gorm:"column:je_fee_amount"`
+	FXQuoteID      *int64           `gorm:"column:je_fx_quote_id"`
+	FXRate         *decimal.Decimal `gorm:"column:je_fx_rate"`
+	FXSpread       *decimal.Decimal `gorm:"column:je_fx_spread"`
+	SourceCurrency *string          `gorm:"column:je_source_currency"`
+	SourceAmount   *decimal.Decimal `gorm:"column:je_source_amount"`
+	TargetCurrency *string          `gorm:"column:je_target_currency"`
+	TargetAmount   *decimal.Decimal `gorm:"column:je_target_amount"`
+	CreatedAt      time.Time        `gorm:"column:created_at"`
+}
+
+func (JournalEntry) TableName() string {
+	return "journal_entry_table"
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// Principal is an authenticated caller. It owns the wallets linked to it in
+// principal_wallet_table and, when it acts for a customer, holds the role that customer
+// has on each wallet in wallet_member_table: an owner manages the wallet and its members,
+// a spender moves money up to its spend limit and a viewer only reads. An admin may
+// operate on every wallet and reverse transactions.
+type Principal struct {
+	ID         int64     `gorm:"column:id"`
+	Name       string    `gorm:"column:prc_name"`
+	IsAdmin    bool      `gorm:"column:prc_is_admin"`
+	CustomerID *int64    `gorm:"column:customer_id"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+func (Principal) TableName() string {
+	return "principal_table"
+}
+
+// APIKey authenticates a principal, only the SHA-256 of the key is stored
+type APIKey struct {
+	ID          int64      `gorm:"column:id"`
+	PrincipalID int64      `gorm:"column:principal_id"`
+	KeyHash     string     `gorm:"column:ak_key_hash"`
+	RevokedAt   *time.Time `gorm:"column:revoked_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_key_table"
+}
+
+type PrincipalWallet struct {
+	PrincipalID int64     `gorm:"column:principal_id"`
+	WalletID    int64     `gorm:"column:wallet_id"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+func (PrincipalWallet) TableName() string {
+	return "principal_wallet_table"
+}
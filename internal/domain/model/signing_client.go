@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// SigningClient is a server-to-server caller that signs its requests, it acts as the
+// principal it belongs to. Secret has to be kept readable to verify the HMAC.
+type SigningClient struct {
+	ID          int64      `gorm:"column:id"`
+	PrincipalID int64      `gorm:"column:principal_id"`
+	ClientID    string     `gorm:"column:sc_client_id"`
+	Secret      string     `gorm:"column:sc_secret"`
+	RevokedAt   *time.Time `gorm:"column:revoked_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+}
+
+func (SigningClient) TableName() string {
+	return "signing_client_table"
+}
@@ -6,14 +6,28 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Transaction is one posting of a journal entry against a wallet.
+// IsDebit true means the wallet balance goes up, IsFee marks the postings collecting a fee. Status follows
+// constant.CanTransitionTransactionStatus, each transition stamps its own column.
+// The initiator is the principal, and the member customer it acted for, that made the request.
 type Transaction struct {
-	ID        int64           `gorm:"column:id"`
-	WalletID  int64           `gorm:"column:wallet_id"`
-	Type      int16           `gorm:"column:trc_type"`
-	IsDebit   bool            `gorm:"column:trc_is_debit"`
-	Value     decimal.Decimal `gorm:"column:trc_value"`
-	Remarks   string          `gorm:"column:trc_remarks"`
-	CreatedAt time.Time       `gorm:"column:created_at"`
+	ID                  int64            `gorm:"column:id"`
+	JournalEntryID      int64            `gorm:"column:journal_entry_id"`
+	WalletID            int64            `gorm:"column:wallet_id"`
+	CounterpartyID      *int64           `gorm:"column:trc_counterparty_wallet_id"`
+	Type                int16            `gorm:"column:trc_type"`
+	IsDebit             bool             `gorm:"column:trc_is_debit"`
+	Value               decimal.Decimal  `gorm:"column:trc_value"`
+	BalanceAfter        *decimal.Decimal `gorm:"column:trc_balance_after"`
+	Remarks             string           `gorm:"column:trc_remarks"`
+	IsFee               bool             `gorm:"column:trc_is_fee"`
+	Status              string           `gorm:"column:trc_status"`
+	InitiatorID         *int64           `gorm:"column:trc_initiator_principal_id"`
+	InitiatorCustomerID *int64           `gorm:"column:trc_initiator_customer_id"`
+	CompletedAt         *time.Time       `gorm:"column:trc_completed_at"`
+	FailedAt            *time.Time       `gorm:"column:trc_failed_at"`
+	ReversedAt          *time.Time       `gorm:"column:trc_reversed_at"`
+	CreatedAt           time.Time        `gorm:"column:created_at"`
 }
 
 func (Transaction) TableName() string {
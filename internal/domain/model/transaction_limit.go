@@ -0,0 +1,24 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// TransactionLimit caps one transaction type for a single wallet, when WalletID is set,
+// or for every wallet of a tier in Currency. A wallet's own limit replaces its tier's limit
+// as a whole. Nil fields are unlimited, amounts are in the wallet currency and periods are
+// UTC days and months.
+type TransactionLimit struct {
+	ID              int64            `gorm:"column:id"`
+	WalletID        *int64           `gorm:"column:wallet_id"`
+	Tier            *string          `gorm:"column:lim_tier"`
+	Currency        *string          `gorm:"column:lim_currency"`
+	TransactionType int16            `gorm:"column:lim_transaction_type"`
+	PerTransaction  *decimal.Decimal `gorm:"column:lim_per_transaction"`
+	DailyAmount     *decimal.Decimal `gorm:"column:lim_daily_amount"`
+	MonthlyAmount   *decimal.Decimal `gorm:"column:lim_monthly_amount"`
+	DailyCount      *int64           `gorm:"column:lim_daily_count"`
+	MonthlyCount    *int64           `gorm:"column:lim_monthly_count"`
+}
+
+func (TransactionLimit) TableName() string {
+	return "transaction_limit_table"
+}
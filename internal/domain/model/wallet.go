@@ -6,13 +6,37 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Wallet is a ledger account. Its balance is the sum of its postings. CurrentBalance
+// caches it for customer wallets, read and moved under the wallet lock by every posting,
+// and the reconciliation job checks it against the postings. System wallets don't keep
+// it, their balance is only derived, see system_wallet_balance_view.
+// HeldBalance is the part of it reserved by active holds and UnclearedBalance the part
+// of it from deposits still in their clearing period. Currency is an ISO 4217 code and
+// never changes, so it can be read without locking the wallet. Tier picks the default limits.
+// CustomerID is the customer owning the wallet, unset for system and unowned wallets.
 type Wallet struct {
-	ID             int64           `gorm:"column:id"`
-	Name           string          `gorm:"column:wallet_name"`
-	CurrentBalance decimal.Decimal `gorm:"column:wallet_curr_balance"`
-	CreatedAt      time.Time       `gorm:"column:created_at"`
-	UpdatedAt      time.Time       `gorm:"column:updated_at"`
-	DeletedAt      *time.Time      `gorm:"column:deleted_at"`
+	ID               int64           `gorm:"column:id"`
+	Name             string          `gorm:"column:wallet_name"`
+	CurrentBalance   decimal.Decimal `gorm:"column:wallet_curr_balance"`
+	HeldBalance      decimal.Decimal `gorm:"column:wallet_held_balance"`
+	UnclearedBalance decimal.Decimal `gorm:"column:wallet_uncleared_balance"`
+	Currency         string          `gorm:"column:wallet_currency"`
+	Tier             string          `gorm:"column:wallet_tier"`
+	SystemCode       *string         `gorm:"column:wallet_system_code"`
+	CustomerID       *int64          `gorm:"column:customer_id"`
+	CreatedAt        time.Time       `gorm:"column:created_at"`
+	UpdatedAt        time.Time       `gorm:"column:updated_at"`
+	DeletedAt        *time.Time      `gorm:"column:deleted_at"`
+}
+
+// AvailableBalance is what the wallet can still spend
+func (w Wallet) AvailableBalance() decimal.Decimal {
+	return w.CurrentBalance.Sub(w.HeldBalance).Sub(w.UnclearedBalance)
+}
+
+// IsSystem reports whether the wallet is an internal counter account like CASH_IN
+func (w Wallet) IsSystem() bool {
+	return w.SystemCode != nil
 }
 
 func (Wallet) TableName() string {
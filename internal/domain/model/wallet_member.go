@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// WalletMember gives a customer a role on a wallet, see constant.WalletRoleOwner.
+// SpendLimit caps what a spender sends out per UTC day, nil is unlimited.
+type WalletMember struct {
+	WalletID   int64            `gorm:"column:wallet_id"`
+	CustomerID int64            `gorm:"column:customer_id"`
+	Role       string           `gorm:"column:wm_role"`
+	SpendLimit *decimal.Decimal `gorm:"column:wm_spend_limit"`
+	CreatedAt  time.Time        `gorm:"column:created_at"`
+	UpdatedAt  time.Time        `gorm:"column:updated_at"`
+}
+
+func (WalletMember) TableName() string {
+	return "wallet_member_table"
+}
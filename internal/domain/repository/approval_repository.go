@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ApprovalRepository interface {
+	FindByID(ctx context.Context, id int64) (*model.ApprovalRequest, error)
+	FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.ApprovalRequest, error)
+	CreateApproval(ctx context.Context, tx *gorm.DB, approval *model.ApprovalRequest) error
+	UpdateApproval(ctx context.Context, tx *gorm.DB, approval model.ApprovalRequest) error
+	GetListApproval(ctx context.Context, status string, limit int) ([]model.ApprovalRequest, error)
+	GetListExpiredApproval(ctx context.Context, now time.Time, limit int) ([]model.ApprovalRequest, error)
+	CreateAudit(ctx context.Context, tx *gorm.DB, audit model.ApprovalAudit) error
+}
+
+type ApprovalRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewApprovalRepository(db *gorm.DB) ApprovalRepository {
+	return &ApprovalRepositoryImpl{db: db}
+}
+
+func (r *ApprovalRepositoryImpl) FindByID(ctx context.Context, id int64) (*model.ApprovalRequest, error) {
+	var approval model.ApprovalRequest
+	err := r.db.WithContext(ctx).
+		Take(&approval, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// FindByIDForUpdate locks the request so only one decision is ever made on it
+func (r *ApprovalRepositoryImpl) FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.ApprovalRequest, error) {
+	var approval model.ApprovalRequest
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(&approval, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &approval, nil
+}
+
+func (r *ApprovalRepositoryImpl) CreateApproval(ctx context.Context, tx *gorm.DB, approval *model.ApprovalRequest) error {
+	return tx.WithContext(ctx).
+		Create(approval).
+		Error
+}
+
+func (r *ApprovalRepositoryImpl) UpdateApproval(ctx context.Context, tx *gorm.DB, approval model.ApprovalRequest) error {
+	return tx.WithContext(ctx).
+		Model(&model.ApprovalRequest{}).
+		Where("id = ?", approval.ID).
+		Updates(map[string]interface{}{
+			"apr_status":               approval.Status,
+			"apr_checker_principal_id": approval.CheckerID,
+			"apr_decision_reason":      approval.DecisionReason,
+			"apr_transaction_id":       approval.TransactionID,
+			"decided_at":               approval.DecidedAt,
+		}).
+		Error
+}
+
+// GetListApproval returns the requests in the status, oldest first so the queue is worked in order
+func (r *ApprovalRepositoryImpl) GetListApproval(ctx context.Context, status string, limit int) ([]model.ApprovalRequest, error) {
+	var approvals []model.ApprovalRequest
+	err := r.db.WithContext(ctx).
+		Where("apr_status = ?", status).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&approvals).
+		Error
+	return approvals, err
+}
+
+func (r *ApprovalRepositoryImpl) GetListExpiredApproval(ctx context.Context, now time.Time, limit int) ([]model.ApprovalRequest, error) {
+	var approvals []model.ApprovalRequest
+	err := r.db.WithContext(ctx).
+		Where("apr_status = ? AND expires_at <= ?", constant.ApprovalStatusPending, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&approvals).
+		Error
+	return approvals, err
+}
+
+func (r *ApprovalRepositoryImpl) CreateAudit(ctx context.Context, tx *gorm.DB, audit model.ApprovalAudit) error {
+	return tx.WithContext(ctx).
+		Create(&audit).
+		Error
+}
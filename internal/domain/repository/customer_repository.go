@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Postgres SQLSTATE of a unique index violation
+const sqlStateUniqueViolation = "23505"
+
+// ErrCustomerEmailTaken is returned when another customer already uses the email
+var ErrCustomerEmailTaken = errors.New("customer email already taken")
+
+type CustomerRepository interface {
+	FindByID(ctx context.Context, id int64) (*model.Customer, error)
+	CreateCustomer(ctx context.Context, customer *model.Customer) error
+	UpdateCustomer(ctx context.Context, customer model.Customer) error
+}
+
+type CustomerRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCustomerRepository(db *gorm.DB) CustomerRepository {
+	return &CustomerRepositoryImpl{db: db}
+}
+
+func (r *CustomerRepositoryImpl) FindByID(ctx context.Context, id int64) (*model.Customer, error) {
+	var customer model.Customer
+	err := r.db.WithContext(ctx).
+		Where("deleted_at IS NULL").
+		Take(&customer, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// CreateCustomer relies on the unique email index, a taken email inserts nothing
+func (r *CustomerRepositoryImpl) CreateCustomer(ctx context.Context, customer *model.Customer) error {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(customer)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCustomerEmailTaken
+	}
+	return nil
+}
+
+func (r *CustomerRepositoryImpl) UpdateCustomer(ctx context.Context, customer model.Customer) error {
+	err := r.db.WithContext(ctx).
+		Model(&model.Customer{}).
+		Where("id = ? AND deleted_at IS NULL", customer.ID).
+		Updates(map[string]interface{}{
+			"cst_name":   customer.Name,
+			"cst_email":  customer.Email,
+			"cst_phone":  customer.Phone,
+			"updated_at": customer.UpdatedAt,
+		}).
+		Error
+	if isUniqueViolation(err) {
+		return ErrCustomerEmailTaken
+	}
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == sqlStateUniqueViolation
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DepositClearingRepository interface {
+	FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.DepositClearing, error)
+	FindPendingByJournalEntryIDForUpdate(ctx context.Context, tx *gorm.DB, journalEntryID int64) (*model.DepositClearing, error)
+	CreateDepositClearing(ctx context.Context, tx *gorm.DB, clearing *model.DepositClearing) error
+	UpdateDepositClearing(ctx context.Context, tx *gorm.DB, clearing model.DepositClearing) error
+	GetListDueDepositClearing(ctx context.Context, now time.Time, limit int) ([]model.DepositClearing, error)
+}
+
+type DepositClearingRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewDepositClearingRepository(db *gorm.DB) DepositClearingRepository {
+	return &DepositClearingRepositoryImpl{db: db}
+}
+
+func (r *DepositClearingRepositoryImpl) FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.DepositClearing, error) {
+	var clearing model.DepositClearing
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(&clearing, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &clearing, nil
+}
+
+// FindPendingByJournalEntryIDForUpdate locks the clearing of a deposit that hasn't cleared yet
+func (r *DepositClearingRepositoryImpl) FindPendingByJournalEntryIDForUpdate(ctx context.Context, tx *gorm.DB, journalEntryID int64) (*model.DepositClearing, error) {
+	var clearing model.DepositClearing
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("journal_entry_id = ? AND cleared_at IS NULL", journalEntryID).
+		Take(&clearing).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &clearing, nil
+}
+
+func (r *DepositClearingRepositoryImpl) CreateDepositClearing(ctx context.Context, tx *gorm.DB, clearing *model.DepositClearing) error {
+	return tx.WithContext(ctx).
+		Create(clearing).
+		Error
+}
+
+func (r *DepositClearingRepositoryImpl) UpdateDepositClearing(ctx context.Context, tx *gorm.DB, clearing model.DepositClearing) error {
+	return tx.WithContext(ctx).
+		Model(&model.DepositClearing{}).
+		Where("id = ?", clearing.ID).
+		Updates(map[string]interface{}{
+			"dc_amount":  clearing.Amount,
+			"cleared_at": clearing.ClearedAt,
+		}).
+		Error
+}
+
+func (r *DepositClearingRepositoryImpl) GetListDueDepositClearing(ctx context.Context, now time.Time, limit int) ([]model.DepositClearing, error) {
+	var clearings []model.DepositClearing
+	err := r.db.WithContext(ctx).
+		Where("cleared_at IS NULL AND clears_at <= ?", now).
+		Order("clears_at").
+		Limit(limit).
+		Find(&clearings).
+		Error
+	return clearings, err
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type FeeScheduleRepository interface {
+	FindActive(ctx context.Context, transactionType int16, currency string) (*model.FeeSchedule, error)
+}
+
+type FeeScheduleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewFeeScheduleRepository(db *gorm.DB) FeeScheduleRepository {
+	return &FeeScheduleRepositoryImpl{db: db}
+}
+
+// FindActive returns nil when no schedule is active, the operation is then free
+func (r *FeeScheduleRepositoryImpl) FindActive(ctx context.Context, transactionType int16, currency string) (*model.FeeSchedule, error) {
+	var schedule model.FeeSchedule
+	err := r.db.WithContext(ctx).
+		Where("fs_transaction_type = ? AND fs_currency = ? AND fs_is_active", transactionType, currency).
+		Take(&schedule).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FXQuoteRepository interface {
+	FindByID(ctx context.Context, id int64) (*model.FXQuote, error)
+	FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.FXQuote, error)
+	CreateFXQuote(ctx context.Context, quote *model.FXQuote) error
+	UpdateJournalEntryID(ctx context.Context, tx *gorm.DB, id int64, journalEntryID int64) error
+	HoldForApproval(ctx context.Context, tx *gorm.DB, id int64, approvalRequestID int64, expiresAt time.Time) error
+}
+
+type FXQuoteRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewFXQuoteRepository(db *gorm.DB) FXQuoteRepository {
+	return &FXQuoteRepositoryImpl{db: db}
+}
+
+func (r *FXQuoteRepositoryImpl) FindByID(ctx context.Context, id int64) (*model.FXQuote, error) {
+	var quote model.FXQuote
+	err := r.db.WithContext(ctx).
+		Take(&quote, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &quote, nil
+}
+
+// FindByIDForUpdate locks the quote so it can only be used by one transfer
+func (r *FXQuoteRepositoryImpl) FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.FXQuote, error) {
+	var quote model.FXQuote
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(&quote, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &quote, nil
+}
+
+func (r *FXQuoteRepositoryImpl) CreateFXQuote(ctx context.Context, quote *model.FXQuote) error {
+	return r.db.WithContext(ctx).
+		Create(quote).
+		Error
+}
+
+func (r *FXQuoteRepositoryImpl) UpdateJournalEntryID(ctx context.Context, tx *gorm.DB, id int64, journalEntryID int64) error {
+	return tx.WithContext(ctx).
+		Model(&model.FXQuote{}).
+		Where("id = ?", id).
+		Update("fxq_journal_entry_id", journalEntryID).
+		Error
+}
+
+// HoldForApproval ties the quote to a transfer waiting for approval and keeps its rate until
+// the approval expires
+func (r *FXQuoteRepositoryImpl) HoldForApproval(ctx context.Context, tx *gorm.DB, id int64, approvalRequestID int64, expiresAt time.Time) error {
+	return tx.WithContext(ctx).
+		Model(&model.FXQuote{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"fxq_approval_request_id": approvalRequestID,
+			"expires_at":              expiresAt,
+		}).
+		Error
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HoldRepository interface {
+	FindByID(ctx context.Context, id int64) (*model.Hold, error)
+	FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.Hold, error)
+	CreateHold(ctx context.Context, tx *gorm.DB, hold *model.Hold) error
+	UpdateHold(ctx context.Context, tx *gorm.DB, hold model.Hold) error
+	GetListExpiredHold(ctx context.Context, now time.Time, limit int) ([]model.Hold, error)
+}
+
+type HoldRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewHoldRepository(db *gorm.DB) HoldRepository {
+	return &HoldRepositoryImpl{db: db}
+}
+
+func (r *HoldRepositoryImpl) FindByID(ctx context.Context, id int64) (*model.Hold, error) {
+	var hold model.Hold
+	err := r.db.WithContext(ctx).Take(&hold, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// FindByIDForUpdate locks the hold row, callers lock the wallet first to keep a single lock order
+func (r *HoldRepositoryImpl) FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.Hold, error) {
+	var hold model.Hold
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(&hold, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func (r *HoldRepositoryImpl) CreateHold(ctx context.Context, tx *gorm.DB, hold *model.Hold) error {
+	return tx.WithContext(ctx).
+		Create(hold).
+		Error
+}
+
+func (r *HoldRepositoryImpl) UpdateHold(ctx context.Context, tx *gorm.DB, hold model.Hold) error {
+	return tx.WithContext(ctx).
+		Model(&model.Hold{}).
+		Where("id = ?", hold.ID).
+		Updates(map[string]interface{}{
+			"hold_status":                   hold.Status,
+			"hold_captured_amount":          hold.CapturedAmount,
+			"hold_capture_journal_entry_id": hold.CaptureJournalEntryID,
+			"updated_at":                    hold.UpdatedAt,
+		}).
+		Error
+}
+
+func (r *HoldRepositoryImpl) GetListExpiredHold(ctx context.Context, now time.Time, limit int) ([]model.Hold, error) {
+	var holds []model.Hold
+	err := r.db.WithContext(ctx).
+		Where("hold_status = ? AND expires_at <= ?", constant.HoldStatusActive, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&holds).
+		Error
+	return holds, err
+}
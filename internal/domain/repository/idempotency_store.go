@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+const (
+	IdempotencyStoreRedis    = "redis"
+	IdempotencyStorePostgres = "postgres"
+)
+
+// ErrIdempotencyKeyNotClaimed is returned by Save when the key was not claimed in the transaction
+var ErrIdempotencyKeyNotClaimed = errors.New("idempotency key was not claimed")
+
+// IdempotencyStore keeps the outcome of requests per idempotency key.
+// Begin is a lookup before the ledger transaction, it returns nil when the caller may go
+// on, otherwise the stored record. Claim is the first thing done in the ledger transaction,
+// it returns nil once the key is claimed for the caller, otherwise the record a concurrent
+// request committed. Save completes the claimed key in the same transaction, Complete is
+// called once it committed and Release when it failed. PurgeExpired deletes up to limit
+// records that expired before now.
+type IdempotencyStore interface {
+	Begin(ctx context.Context, key string, fingerprint string) (*model.IdempotencyRecord, error)
+	Claim(ctx context.Context, tx *gorm.DB, key string, fingerprint string) (*model.IdempotencyRecord, error)
+	Save(ctx context.Context, tx *gorm.DB, record model.IdempotencyRecord) error
+	Complete(ctx context.Context, record model.IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
+	PurgeExpired(ctx context.Context, now time.Time, limit int) (int64, error)
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresIdempotencyStore writes the key in the same transaction as the ledger
+// entries, so a request is applied exactly once without any external store
+type PostgresIdempotencyStore struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+func NewPostgresIdempotencyStore(db *gorm.DB, ttl time.Duration) IdempotencyStore {
+	return &PostgresIdempotencyStore{db: db, ttl: ttl}
+}
+
+// Begin only looks a committed key up, the key is claimed by Claim
+func (s *PostgresIdempotencyStore) Begin(ctx context.Context, key string, fingerprint string) (*model.IdempotencyRecord, error) {
+	return s.findLive(s.db.WithContext(ctx), key)
+}
+
+// Claim inserts the key as in progress before the request runs. A concurrent duplicate
+// blocks on the primary key until the first transaction ends, then gets the record it
+// committed, or claims the key itself when the first one rolled back. An expired key may
+// be claimed again.
+func (s *PostgresIdempotencyStore) Claim(ctx context.Context, tx *gorm.DB, key string, fingerprint string) (*model.IdempotencyRecord, error) {
+	for {
+		now := time.Now()
+		record := model.IdempotencyRecord{
+			Key:         key,
+			Status:      model.IdempotencyStatusInProgress,
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(s.ttl),
+		}
+		result := tx.WithContext(ctx).
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "idem_key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"idem_status", "idem_fingerprint", "idem_status_code", "idem_body", "created_at", "expires_at"}),
+				Where: clause.Where{Exprs: []clause.Expression{
+					clause.Lt{Column: clause.Column{Table: "idempotency_table", Name: "expires_at"}, Value: now},
+				}},
+			}).
+			Create(&record)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		existing, err := s.findLive(tx.WithContext(ctx), key)
+		if err != nil {
+			return nil, err
+		}
+		// Otherwise it expired or was purged since the insert, try to claim it again
+		if existing != nil {
+			return existing, nil
+		}
+	}
+}
+
+// Save completes the key claimed in the same transaction
+func (s *PostgresIdempotencyStore) Save(ctx context.Context, tx *gorm.DB, record model.IdempotencyRecord) error {
+	result := tx.WithContext(ctx).
+		Model(&model.IdempotencyRecord{}).
+		Where("idem_key = ? AND idem_status = ?", record.Key, model.IdempotencyStatusInProgress).
+		Updates(map[string]interface{}{
+			"idem_status":      record.Status,
+			"idem_status_code": record.StatusCode,
+			"idem_body":        record.Body,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdempotencyKeyNotClaimed
+	}
+	return nil
+}
+
+// Complete has nothing to do, the row was committed with the ledger transaction
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, record model.IdempotencyRecord) error {
+	return nil
+}
+
+// Release has nothing to do, the row was rolled back with the ledger transaction
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, key string) error {
+	return nil
+}
+
+// PurgeExpired deletes expired keys in batches, an expired key is never replayed anyway
+func (s *PostgresIdempotencyStore) PurgeExpired(ctx context.Context, now time.Time, limit int) (int64, error) {
+	expired := s.db.
+		Model(&model.IdempotencyRecord{}).
+		Select("idem_key").
+		Where("expires_at <= ?", now).
+		Limit(limit)
+
+	result := s.db.WithContext(ctx).
+		Where("idem_key IN (?)", expired).
+		Delete(&model.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *PostgresIdempotencyStore) findLive(db *gorm.DB, key string) (*model.IdempotencyRecord, error) {
+	var record model.IdempotencyRecord
+	err := db.
+		Where("idem_key = ? AND expires_at > ?", key, time.Now()).
+		Take(&record).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// How long a key stays locked while its request is running, guards against a crashed worker
+// holding it forever. A duplicate let through once it lapsed still can't post twice, it
+// waits on the key claimed in Postgres and replays the committed record.
+const redisIdempotencyInProgressTTL = time.Minute
+
+// RedisIdempotencyStore puts Redis in front of the Postgres store. Redis turns concurrent
+// duplicates away and replays completed responses without a query, while the key claimed
+// in Postgres with the ledger entries stays the source of truth.
+type RedisIdempotencyStore struct {
+	redis    *redis.Client
+	postgres *PostgresIdempotencyStore
+	ttl      time.Duration
+}
+
+func NewRedisIdempotencyStore(redis *redis.Client, db *gorm.DB, ttl time.Duration) IdempotencyStore {
+	return &RedisIdempotencyStore{
+		redis:    redis,
+		postgres: &PostgresIdempotencyStore{db: db, ttl: ttl},
+		ttl:      ttl,
+	}
+}
+
+// Begin claims the key with SETNX so concurrent duplicates see it as in progress. Once
+// claimed, Postgres is checked for a request that committed but never reached Redis.
+func (s *RedisIdempotencyStore) Begin(ctx context.Context, key string, fingerprint string) (*model.IdempotencyRecord, error) {
+	inProgress, err := json.Marshal(model.IdempotencyRecord{
+		Status:      model.IdempotencyStatusInProgress,
+		Fingerprint: fingerprint,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		claimed, err := s.redis.SetNX(ctx, key, inProgress, redisIdempotencyInProgressTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			record, err := s.postgres.Begin(ctx, key, fingerprint)
+			if err != nil {
+				s.redis.Del(ctx, key)
+				return nil, err
+			}
+			if record != nil {
+				// Only a cache, the next duplicate reads Postgres again if this fails
+				record.Key = key
+				s.Complete(ctx, *record)
+			}
+			return record, nil
+		}
+
+		raw, err := s.redis.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			// Expired or released between SETNX and GET, try to claim it again
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := model.IdempotencyRecord{}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, err
+		}
+		record.Key = key
+		return &record, nil
+	}
+}
+
+// Claim claims the authoritative key in the ledger transaction
+func (s *RedisIdempotencyStore) Claim(ctx context.Context, tx *gorm.DB, key string, fingerprint string) (*model.IdempotencyRecord, error) {
+	return s.postgres.Claim(ctx, tx, key, fingerprint)
+}
+
+// Save completes the authoritative key in the ledger transaction
+func (s *RedisIdempotencyStore) Save(ctx context.Context, tx *gorm.DB, record model.IdempotencyRecord) error {
+	return s.postgres.Save(ctx, tx, record)
+}
+
+// Complete caches the committed record, it must not run before the commit or a duplicate
+// could replay a success that is rolled back
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, record model.IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(ctx, record.Key, data, s.ttl).Err()
+}
+
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.redis.Del(ctx, key).Err()
+}
+
+// PurgeExpired only has Postgres to clean up, Redis expires the keys itself
+func (s *RedisIdempotencyStore) PurgeExpired(ctx context.Context, now time.Time, limit int) (int64, error) {
+	return s.postgres.PurgeExpired(ctx, now, limit)
+}
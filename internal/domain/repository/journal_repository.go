@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JournalRepository interface {
+	FindByID(ctx context.Context, id int64) (*model.JournalEntry, error)
+	FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.JournalEntry, error)
+	CreateJournalEntry(ctx context.Context, tx *gorm.DB, entry model.JournalEntry) (int64, error)
+	UpdateReversedAmount(ctx context.Context, tx *gorm.DB, id int64, reversedAmount decimal.Decimal) error
+}
+
+type JournalRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewJournalRepository(db *gorm.DB) JournalRepository {
+	return &JournalRepositoryImpl{db: db}
+}
+
+func (r *JournalRepositoryImpl) FindByID(ctx context.Context, id int64) (*model.JournalEntry, error) {
+	var entry model.JournalEntry
+	err := r.db.WithContext(ctx).Take(&entry, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// FindByIDForUpdate locks the entry, concurrent reversals of the same entry run one after the other
+func (r *JournalRepositoryImpl) FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.JournalEntry, error) {
+	var entry model.JournalEntry
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(&entry, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *JournalRepositoryImpl) CreateJournalEntry(ctx context.Context, tx *gorm.DB, entry model.JournalEntry) (int64, error) {
+	err := tx.WithContext(ctx).Create(&entry).Error
+	return entry.ID, err
+}
+
+func (r *JournalRepositoryImpl) UpdateReversedAmount(ctx context.Context, tx *gorm.DB, id int64, reversedAmount decimal.Decimal) error {
+	return tx.WithContext(ctx).
+		Model(&model.JournalEntry{}).
+		Where("id = ?", id).
+		Update("je_reversed_amount", reversedAmount).
+		Error
+}
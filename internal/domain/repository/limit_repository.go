@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type LimitRepository interface {
+	FindForWallet(ctx context.Context, wallet model.Wallet, transactionType int16) (*model.TransactionLimit, error)
+}
+
+type LimitRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLimitRepository(db *gorm.DB) LimitRepository {
+	return &LimitRepositoryImpl{db: db}
+}
+
+// FindForWallet returns the wallet's own limit, else the limit of its tier in its currency,
+// nil when neither exists
+func (r *LimitRepositoryImpl) FindForWallet(ctx context.Context, wallet model.Wallet, transactionType int16) (*model.TransactionLimit, error) {
+	var limit model.TransactionLimit
+	err := r.db.WithContext(ctx).
+		Where("lim_transaction_type = ?", transactionType).
+		Where("wallet_id = ? OR (wallet_id IS NULL AND lim_tier = ? AND lim_currency = ?)", wallet.ID, wallet.Tier, wallet.Currency).
+		Order("wallet_id NULLS LAST").
+		Take(&limit).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &limit, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NonceStore remembers the nonces of signed requests so none can be replayed.
+// Claim returns false when the nonce was already used within ttl.
+type NonceStore interface {
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+type RedisNonceStore struct {
+	redis *redis.Client
+}
+
+func NewRedisNonceStore(redis *redis.Client) NonceStore {
+	return &RedisNonceStore{redis: redis}
+}
+
+// Claim uses SETNX, so of two requests racing with the same nonce only one gets through
+func (s *RedisNonceStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.redis.SetNX(ctx, key, 1, ttl).Result()
+}
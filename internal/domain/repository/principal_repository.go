@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+type PrincipalRepository interface {
+	FindByID(ctx context.Context, id int64) (*model.Principal, error)
+	FindByAPIKeyHash(ctx context.Context, keyHash string) (*model.Principal, error)
+	FindSigningClient(ctx context.Context, clientID string) (*model.SigningClient, error)
+	GetListWalletID(ctx context.Context, principalID int64) ([]int64, error)
+	AddWallet(ctx context.Context, tx *gorm.DB, principalID int64, walletID int64) error
+}
+
+type PrincipalRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPrincipalRepository(db *gorm.DB) PrincipalRepository {
+	return &PrincipalRepositoryImpl{db: db}
+}
+
+func (r *PrincipalRepositoryImpl) FindByID(ctx context.Context, id int64) (*model.Principal, error) {
+	var principal model.Principal
+	err := r.db.WithContext(ctx).
+		Take(&principal, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &principal, nil
+}
+
+// FindByAPIKeyHash returns the owner of a key that is not revoked
+func (r *PrincipalRepositoryImpl) FindByAPIKeyHash(ctx context.Context, keyHash string) (*model.Principal, error) {
+	var principal model.Principal
+	err := r.db.WithContext(ctx).
+		Joins(`JOIN "api_key_table" ON "api_key_table".principal_id = "principal_table".id`).
+		Where(`"api_key_table".ak_key_hash = ? AND "api_key_table".revoked_at IS NULL`, keyHash).
+		Take(&principal).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &principal, nil
+}
+
+// FindSigningClient returns a signing client that is not revoked
+func (r *PrincipalRepositoryImpl) FindSigningClient(ctx context.Context, clientID string) (*model.SigningClient, error) {
+	var client model.SigningClient
+	err := r.db.WithContext(ctx).
+		Where("sc_client_id = ? AND revoked_at IS NULL", clientID).
+		Take(&client).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *PrincipalRepositoryImpl) GetListWalletID(ctx context.Context, principalID int64) ([]int64, error) {
+	var walletIDs []int64
+	err := r.db.WithContext(ctx).
+		Model(&model.PrincipalWallet{}).
+		Where("principal_id = ?", principalID).
+		Pluck("wallet_id", &walletIDs).
+		Error
+	return walletIDs, err
+}
+
+func (r *PrincipalRepositoryImpl) AddWallet(ctx context.Context, tx *gorm.DB, principalID int64, walletID int64) error {
+	return tx.WithContext(ctx).
+		Create(&model.PrincipalWallet{
+			PrincipalID: principalID,
+			WalletID:    walletID,
+			CreatedAt:   time.Now(),
+		}).
+		Error
+}
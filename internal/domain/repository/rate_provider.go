@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+)
+
+const (
+	RateProviderPostgres = "postgres"
+	RateProviderFile     = "file"
+)
+
+// RateProvider looks up mid-market exchange rates. GetRate returns nil when the provider
+// has no rate for the pair in that direction, callers may try the inverse pair.
+type RateProvider interface {
+	GetRate(ctx context.Context, base string, quote string) (*model.ExchangeRate, error)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+)
+
+// FileRateProvider serves rates loaded once from a JSON file, for running without a rate
+// feed. The file holds {"rates": [{"base": "USD", "quote": "EUR", "rate": "0.92"}]}.
+type FileRateProvider struct {
+	rates map[string]model.ExchangeRate
+}
+
+func NewFileRateProvider(path string) (RateProvider, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Rates []model.ExchangeRate `json:"rates"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make(map[string]model.ExchangeRate, len(file.Rates))
+	for _, rate := range file.Rates {
+		if rate.UpdatedAt.IsZero() {
+			rate.UpdatedAt = info.ModTime()
+		}
+		rates[rate.BaseCurrency+"/"+rate.QuoteCurrency] = rate
+	}
+	return &FileRateProvider{rates: rates}, nil
+}
+
+func (p *FileRateProvider) GetRate(ctx context.Context, base string, quote string) (*model.ExchangeRate, error) {
+	rate, ok := p.rates[base+"/"+quote]
+	if !ok {
+		return nil, nil
+	}
+	return &rate, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// PostgresRateProvider reads rates from exchange_rate_table, kept up to date out of band
+type PostgresRateProvider struct {
+	db *gorm.DB
+}
+
+func NewPostgresRateProvider(db *gorm.DB) RateProvider {
+	return &PostgresRateProvider{db: db}
+}
+
+func (p *PostgresRateProvider) GetRate(ctx context.Context, base string, quote string) (*model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+	err := p.db.WithContext(ctx).
+		Where("er_base_currency = ? AND er_quote_currency = ?", base, quote).
+		Take(&rate).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
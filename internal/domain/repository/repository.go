@@ -1,15 +1,73 @@
 package repository
 
-import "gorm.io/gorm"
+import (
+	"github.com/krisnadwipayana07/restful-fintech/configs"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
 
 type Repository struct {
 	Wallet      WalletRepository
 	Transaction TransactionRepository
+	Journal     JournalRepository
+	Hold        HoldRepository
+	Clearing    DepositClearingRepository
+	FXQuote     FXQuoteRepository
+	FeeSchedule FeeScheduleRepository
+	Limit       LimitRepository
+	Principal   PrincipalRepository
+	Customer    CustomerRepository
+	Member      WalletMemberRepository
+	Approval    ApprovalRepository
+	Idempotency IdempotencyStore
+	Rate        RateProvider
+	Nonce       NonceStore
 }
 
-func New(db *gorm.DB) (Repository, error) {
+// New wires the repositories, redis is only needed when it backs the idempotency store or
+// request signing is on, and the rate file only when it backs the rate provider
+func New(db *gorm.DB, redis *redis.Client, config *configs.Config) (Repository, error) {
+	var idempotencyStore IdempotencyStore
+	switch config.IdempotencyStore {
+	case IdempotencyStorePostgres:
+		idempotencyStore = NewPostgresIdempotencyStore(db, config.IdempotencyTTL)
+	default:
+		idempotencyStore = NewRedisIdempotencyStore(redis, db, config.IdempotencyTTL)
+	}
+
+	var rateProvider RateProvider
+	switch config.RateProvider {
+	case RateProviderFile:
+		provider, err := NewFileRateProvider(config.RateFile)
+		if err != nil {
+			return Repository{}, err
+		}
+		rateProvider = provider
+	default:
+		rateProvider = NewPostgresRateProvider(db)
+	}
+
+	// Without request signing there are no nonces to track
+	var nonceStore NonceStore
+	if config.RequestSigning {
+		nonceStore = NewRedisNonceStore(redis)
+	}
+
 	return Repository{
 		Wallet:      NewAccountRepository(db),
 		Transaction: NewTransactionRepository(db),
+		Journal:     NewJournalRepository(db),
+		Hold:        NewHoldRepository(db),
+		Clearing:    NewDepositClearingRepository(db),
+		FXQuote:     NewFXQuoteRepository(db),
+		FeeSchedule: NewFeeScheduleRepository(db),
+		Limit:       NewLimitRepository(db),
+		Principal:   NewPrincipalRepository(db),
+		Customer:    NewCustomerRepository(db),
+		Member:      NewWalletMemberRepository(db),
+		Approval:    NewApprovalRepository(db),
+		Idempotency: idempotencyStore,
+		Rate:        rateProvider,
+		Nonce:       nonceStore,
 	}, nil
 }
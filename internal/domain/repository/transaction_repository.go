@@ -2,15 +2,58 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
+// TransactionFilter narrows a wallet history query. Nil fields are not filtered on.
+// Results are ordered newest first by (created_at, id), After continues a previous page.
+type TransactionFilter struct {
+	Type      *int16
+	IsDebit   *bool
+	MinAmount *decimal.Decimal
+	MaxAmount *decimal.Decimal
+	From      *time.Time
+	To        *time.Time
+	After     *TransactionCursor
+	Limit     int
+}
+
+// TransactionCursor is the position of the last row of a page
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// ErrTransactionStatusChanged is returned by UpdateStatus when the row is no longer in the expected status
+var ErrTransactionStatusChanged = errors.New("transaction status changed concurrently")
+
+// transactionStatusColumns is the timestamp column stamped when a row enters the status
+var transactionStatusColumns = map[string]string{
+	constant.TransactionStatusCompleted: "trc_completed_at",
+	constant.TransactionStatusFailed:    "trc_failed_at",
+	constant.TransactionStatusReversed:  "trc_reversed_at",
+}
+
+// TransactionUsage is how much a wallet sent out and in how many postings
+type TransactionUsage struct {
+	Amount decimal.Decimal
+	Count  int64
+}
+
 type TransactionRepository interface {
 	FindByID(ctx context.Context, id int64) (*model.Transaction, error)
 	CreateTransaction(ctx context.Context, tx *gorm.DB, transaction model.Transaction) (int64, error)
-	GetListTransactionByWalletID(ctx context.Context, walletID int64) ([]model.Transaction, error)
+	GetListTransactionByWalletID(ctx context.Context, walletID int64, filter TransactionFilter) ([]model.Transaction, error)
+	GetListTransactionByJournalEntryID(ctx context.Context, journalEntryID int64) ([]model.Transaction, error)
+	UpdateStatus(ctx context.Context, tx *gorm.DB, id int64, from string, to string, at time.Time) error
+	GetOutgoingUsage(ctx context.Context, tx *gorm.DB, walletID int64, transactionTypes []int16, since time.Time) (TransactionUsage, error)
+	GetMemberSpend(ctx context.Context, tx *gorm.DB, walletID int64, customerID int64, since time.Time) (decimal.Decimal, error)
 }
 
 type TransactionRepositoryImpl struct {
@@ -24,6 +67,9 @@ func NewTransactionRepository(db *gorm.DB) TransactionRepository {
 func (r *TransactionRepositoryImpl) FindByID(ctx context.Context, id int64) (*model.Transaction, error) {
 	var transaction model.Transaction
 	if err := r.db.WithContext(ctx).First(&transaction, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &transaction, nil
@@ -34,8 +80,130 @@ func (r *TransactionRepositoryImpl) CreateTransaction(ctx context.Context, tx *g
 	return transaction.ID, err
 }
 
-func (r *TransactionRepositoryImpl) GetListTransactionByWalletID(ctx context.Context, walletID int64) ([]model.Transaction, error) {
+// GetListTransactionByWalletID is served by idx_transaction_table_wallet_id_created_at_id
+func (r *TransactionRepositoryImpl) GetListTransactionByWalletID(ctx context.Context, walletID int64, filter TransactionFilter) ([]model.Transaction, error) {
+	query := r.db.WithContext(ctx).Where("wallet_id = ?", walletID)
+
+	if filter.Type != nil {
+		query = query.Where("trc_type = ?", *filter.Type)
+	}
+	if filter.IsDebit != nil {
+		query = query.Where("trc_is_debit = ?", *filter.IsDebit)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where("trc_value >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where("trc_value <= ?", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.After != nil {
+		query = query.Where("(created_at, id) < (?, ?)", filter.After.CreatedAt, filter.After.ID)
+	}
+
+	var transactions []model.Transaction
+	err := query.
+		Order("created_at DESC, id DESC").
+		Limit(filter.Limit).
+		Find(&transactions).
+		Error
+	return transactions, err
+}
+
+func (r *TransactionRepositoryImpl) GetListTransactionByJournalEntryID(ctx context.Context, journalEntryID int64) ([]model.Transaction, error) {
 	var transactions []model.Transaction
-	err := r.db.WithContext(ctx).Where("wallet_id = ?", walletID).Find(&transactions).Error
+	err := r.db.WithContext(ctx).
+		Where("journal_entry_id = ?", journalEntryID).
+		Order("id").
+		Find(&transactions).
+		Error
 	return transactions, err
 }
+
+// UpdateStatus moves the row from one status to another, only if it is still in from
+func (r *TransactionRepositoryImpl) UpdateStatus(ctx context.Context, tx *gorm.DB, id int64, from string, to string, at time.Time) error {
+	updates := map[string]interface{}{"trc_status": to}
+	if column, ok := transactionStatusColumns[to]; ok {
+		updates[column] = at
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&model.Transaction{}).
+		Where("id = ? AND trc_status = ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTransactionStatusChanged
+	}
+	return nil
+}
+
+// GetOutgoingUsage sums the postings of the types that took money out of the wallet since
+// the given time, fees and failed postings excluded. Read inside tx under the wallet lock
+// it can't change before the caller posts.
+func (r *TransactionRepositoryImpl) GetOutgoingUsage(ctx context.Context, tx *gorm.DB, walletID int64, transactionTypes []int16, since time.Time) (TransactionUsage, error) {
+	var usage struct {
+		Amount decimal.NullDecimal
+		Count  int64
+	}
+	err := tx.WithContext(ctx).
+		Model(&model.Transaction{}).
+		Select("SUM(trc_value) AS amount, COUNT(*) AS count").
+		Where("wallet_id = ? AND trc_type IN ? AND trc_is_debit = ? AND trc_is_fee = ?", walletID, transactionTypes, false, false).
+		Where("trc_status <> ? AND created_at >= ?", constant.TransactionStatusFailed, since).
+		Scan(&usage).
+		Error
+	if err != nil {
+		return TransactionUsage{}, err
+	}
+
+	amount := decimal.Zero
+	if usage.Amount.Valid {
+		amount = usage.Amount.Decimal
+	}
+	return TransactionUsage{Amount: amount, Count: usage.Count}, nil
+}
+
+// GetMemberSpend sums what the customer sent out of the wallet since the given time, of
+// every type, fees and failed postings excluded, and the holds it placed that are still
+// open whenever they were placed
+func (r *TransactionRepositoryImpl) GetMemberSpend(ctx context.Context, tx *gorm.DB, walletID int64, customerID int64, since time.Time) (decimal.Decimal, error) {
+	var spend decimal.NullDecimal
+	err := tx.WithContext(ctx).
+		Model(&model.Transaction{}).
+		Select("SUM(trc_value)").
+		Where("wallet_id = ? AND trc_initiator_customer_id = ? AND trc_is_debit = ? AND trc_is_fee = ?", walletID, customerID, false, false).
+		Where("trc_status <> ? AND created_at >= ?", constant.TransactionStatusFailed, since).
+		Scan(&spend).
+		Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	var held decimal.NullDecimal
+	err = tx.WithContext(ctx).
+		Model(&model.Hold{}).
+		Select("SUM(hold_amount)").
+		Where("wallet_id = ? AND hold_initiator_customer_id = ? AND hold_status = ?", walletID, customerID, constant.HoldStatusActive).
+		Scan(&held).
+		Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	total := decimal.Zero
+	if spend.Valid {
+		total = total.Add(spend.Decimal)
+	}
+	if held.Valid {
+		total = total.Add(held.Decimal)
+	}
+	return total, nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WalletMemberRepository interface {
+	FindMember(ctx context.Context, walletID int64, customerID int64) (*model.WalletMember, error)
+	GetListByWalletID(ctx context.Context, walletID int64) ([]model.WalletMember, error)
+	GetListByCustomerID(ctx context.Context, customerID int64) ([]model.WalletMember, error)
+	LockOwners(ctx context.Context, tx *gorm.DB, walletID int64) ([]model.WalletMember, error)
+	SaveMember(ctx context.Context, tx *gorm.DB, member model.WalletMember) error
+	DeleteMember(ctx context.Context, tx *gorm.DB, walletID int64, customerID int64) error
+}
+
+type WalletMemberRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWalletMemberRepository(db *gorm.DB) WalletMemberRepository {
+	return &WalletMemberRepositoryImpl{db: db}
+}
+
+func (r *WalletMemberRepositoryImpl) FindMember(ctx context.Context, walletID int64, customerID int64) (*model.WalletMember, error) {
+	var member model.WalletMember
+	err := r.db.WithContext(ctx).
+		Where("wallet_id = ? AND customer_id = ?", walletID, customerID).
+		Take(&member).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *WalletMemberRepositoryImpl) GetListByWalletID(ctx context.Context, walletID int64) ([]model.WalletMember, error) {
+	var members []model.WalletMember
+	err := r.db.WithContext(ctx).
+		Where("wallet_id = ?", walletID).
+		Order("created_at ASC").
+		Find(&members).
+		Error
+	return members, err
+}
+
+func (r *WalletMemberRepositoryImpl) GetListByCustomerID(ctx context.Context, customerID int64) ([]model.WalletMember, error) {
+	var members []model.WalletMember
+	err := r.db.WithContext(ctx).
+		Where("customer_id = ?", customerID).
+		Find(&members).
+		Error
+	return members, err
+}
+
+// LockOwners locks the owner rows of the wallet, so two owners can't demote each other at
+// once. The longest standing owner comes first.
+func (r *WalletMemberRepositoryImpl) LockOwners(ctx context.Context, tx *gorm.DB, walletID int64) ([]model.WalletMember, error) {
+	var members []model.WalletMember
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("wallet_id = ? AND wm_role = ?", walletID, constant.WalletRoleOwner).
+		Order("created_at ASC, customer_id ASC").
+		Find(&members).
+		Error
+	return members, err
+}
+
+// SaveMember adds the member or replaces its role and spend limit
+func (r *WalletMemberRepositoryImpl) SaveMember(ctx context.Context, tx *gorm.DB, member model.WalletMember) error {
+	return tx.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "wallet_id"}, {Name: "customer_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"wm_role", "wm_spend_limit", "updated_at"}),
+		}).
+		Create(&member).
+		Error
+}
+
+func (r *WalletMemberRepositoryImpl) DeleteMember(ctx context.Context, tx *gorm.DB, walletID int64, customerID int64) error {
+	return tx.WithContext(ctx).
+		Where("wallet_id = ? AND customer_id = ?", walletID, customerID).
+		Delete(&model.WalletMember{}).
+		Error
+}
@@ -3,16 +3,38 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// WalletBalanceDrift is a customer wallet whose cached balance differs from its postings
+type WalletBalanceDrift struct {
+	WalletID      int64
+	Balance       decimal.Decimal
+	PostedBalance decimal.Decimal
+}
+
 type WalletRepository interface {
 	FindByID(ctx context.Context, id int64) (*model.Wallet, error)
-	CreateWallet(ctx context.Context, account *model.Wallet) error
+	GetListSystemByIDs(ctx context.Context, ids []int64) ([]model.Wallet, error)
+	FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.Wallet, error)
+	FindSystemWallet(ctx context.Context, code string, currency string) (*model.Wallet, error)
+	CreateSystemWallet(ctx context.Context, code string, currency string) error
+	GetListByCustomerID(ctx context.Context, customerID int64) ([]model.Wallet, error)
+	CreateWallet(ctx context.Context, tx *gorm.DB, account *model.Wallet) error
+	UpdateName(ctx context.Context, walletID int64, name string) error
+	UpdateCustomerID(ctx context.Context, tx *gorm.DB, walletID int64, customerID *int64) error
+	CloseWallet(ctx context.Context, tx *gorm.DB, walletID int64) error
 	UpdateBalance(ctx context.Context, tx *gorm.DB, walletID int64, newBalance decimal.Decimal) error
+	UpdateHeldBalance(ctx context.Context, tx *gorm.DB, walletID int64, heldBalance decimal.Decimal) error
+	UpdateUnclearedBalance(ctx context.Context, tx *gorm.DB, walletID int64, unclearedBalance decimal.Decimal) error
+	GetListBalanceDrift(ctx context.Context, limit int) ([]WalletBalanceDrift, error)
 }
 
 type WalletRepositoryImpl struct {
@@ -23,9 +45,13 @@ func NewAccountRepository(db *gorm.DB) WalletRepository {
 	return &WalletRepositoryImpl{db: db}
 }
 
+// FindByID only returns open customer wallets, closed and system wallets are treated as not found
 func (r *WalletRepositoryImpl) FindByID(ctx context.Context, id int64) (*model.Wallet, error) {
 	var account model.Wallet
-	err := r.db.WithContext(ctx).Take(&account, id).Error
+	err := r.db.WithContext(ctx).
+		Where("deleted_at IS NULL AND wallet_system_code IS NULL").
+		Take(&account, id).
+		Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // Explicitly return nil to indicate no data found
@@ -35,12 +61,125 @@ func (r *WalletRepositoryImpl) FindByID(ctx context.Context, id int64) (*model.W
 	return &account, nil
 }
 
-func (r *WalletRepositoryImpl) CreateWallet(ctx context.Context, account *model.Wallet) error {
+// GetListSystemByIDs returns the system wallets among ids, read without locking them
+func (r *WalletRepositoryImpl) GetListSystemByIDs(ctx context.Context, ids []int64) ([]model.Wallet, error) {
+	var wallets []model.Wallet
+	err := r.db.WithContext(ctx).
+		Where("id IN ? AND wallet_system_code IS NOT NULL", ids).
+		Find(&wallets).
+		Error
+	return wallets, err
+}
+
+// FindByIDForUpdate locks the wallet row with SELECT ... FOR UPDATE until tx ends.
+// System wallets are returned as well, callers check Wallet.IsSystem.
+func (r *WalletRepositoryImpl) FindByIDForUpdate(ctx context.Context, tx *gorm.DB, id int64) (*model.Wallet, error) {
+	var account model.Wallet
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("deleted_at IS NULL").
+		Take(&account, id).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *WalletRepositoryImpl) FindSystemWallet(ctx context.Context, code string, currency string) (*model.Wallet, error) {
+	var account model.Wallet
+	err := r.db.WithContext(ctx).
+		Where("wallet_system_code = ? AND wallet_currency = ?", code, currency).
+		Take(&account).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// CreateSystemWallet adds the system wallet of a currency, doing nothing when a concurrent
+// request already created it
+func (r *WalletRepositoryImpl) CreateSystemWallet(ctx context.Context, code string, currency string) error {
+	now := time.Now()
 	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.Wallet{
+			Name:             fmt.Sprintf("System - %s %s", code, currency),
+			CurrentBalance:   decimal.Zero,
+			HeldBalance:      decimal.Zero,
+			UnclearedBalance: decimal.Zero,
+			Currency:         currency,
+			Tier:             constant.WalletTierStandard,
+			SystemCode:       &code,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}).
+		Error
+}
+
+// GetListByCustomerID returns the open wallets the customer is a member of, in any role,
+// oldest first
+func (r *WalletRepositoryImpl) GetListByCustomerID(ctx context.Context, customerID int64) ([]model.Wallet, error) {
+	var wallets []model.Wallet
+	err := r.db.WithContext(ctx).
+		Joins(`JOIN "wallet_member_table" ON "wallet_member_table".wallet_id = "wallet_table".id`).
+		Where(`"wallet_member_table".customer_id = ?`, customerID).
+		Where(`"wallet_table".deleted_at IS NULL AND "wallet_table".wallet_system_code IS NULL`).
+		Order(`"wallet_table".id ASC`).
+		Find(&wallets).
+		Error
+	return wallets, err
+}
+
+// UpdateCustomerID points the wallet at the customer owning it, nil when no customer does
+func (r *WalletRepositoryImpl) UpdateCustomerID(ctx context.Context, tx *gorm.DB, walletID int64, customerID *int64) error {
+	return tx.WithContext(ctx).
+		Model(&model.Wallet{}).
+		Where("id = ?", walletID).
+		Updates(map[string]interface{}{
+			"customer_id": customerID,
+			"updated_at":  time.Now(),
+		}).
+		Error
+}
+
+func (r *WalletRepositoryImpl) CreateWallet(ctx context.Context, tx *gorm.DB, account *model.Wallet) error {
+	return tx.WithContext(ctx).
 		Create(account).
 		Error
 }
 
+func (r *WalletRepositoryImpl) UpdateName(ctx context.Context, walletID int64, name string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Wallet{}).
+		Where("id = ? AND deleted_at IS NULL", walletID).
+		Updates(map[string]interface{}{
+			"wallet_name": name,
+			"updated_at":  time.Now(),
+		}).
+		Error
+}
+
+// CloseWallet soft deletes the wallet by setting deleted_at
+func (r *WalletRepositoryImpl) CloseWallet(ctx context.Context, tx *gorm.DB, walletID int64) error {
+	now := time.Now()
+	return tx.WithContext(ctx).
+		Model(&model.Wallet{}).
+		Where("id = ? AND deleted_at IS NULL", walletID).
+		Updates(map[string]interface{}{
+			"deleted_at": now,
+			"updated_at": now,
+		}).
+		Error
+}
+
 func (r *WalletRepositoryImpl) UpdateBalance(ctx context.Context, tx *gorm.DB, walletID int64, newBalance decimal.Decimal) error {
 	return tx.WithContext(ctx).
 		Model(&model.Wallet{}).
@@ -48,3 +187,37 @@ func (r *WalletRepositoryImpl) UpdateBalance(ctx context.Context, tx *gorm.DB, w
 		Update("wallet_curr_balance", newBalance).
 		Error
 }
+
+func (r *WalletRepositoryImpl) UpdateHeldBalance(ctx context.Context, tx *gorm.DB, walletID int64, heldBalance decimal.Decimal) error {
+	return tx.WithContext(ctx).
+		Model(&model.Wallet{}).
+		Where("id = ?", walletID).
+		Update("wallet_held_balance", heldBalance).
+		Error
+}
+
+func (r *WalletRepositoryImpl) UpdateUnclearedBalance(ctx context.Context, tx *gorm.DB, walletID int64, unclearedBalance decimal.Decimal) error {
+	return tx.WithContext(ctx).
+		Model(&model.Wallet{}).
+		Where("id = ?", walletID).
+		Update("wallet_uncleared_balance", unclearedBalance).
+		Error
+}
+
+// GetListBalanceDrift compares the cached balance of every customer wallet with the sum of
+// its postings and returns up to limit wallets where they differ
+func (r *WalletRepositoryImpl) GetListBalanceDrift(ctx context.Context, limit int) ([]WalletBalanceDrift, error) {
+	var drifts []WalletBalanceDrift
+	err := r.db.WithContext(ctx).
+		Table(`"wallet_table" AS w`).
+		Select(`w.id AS wallet_id, w.wallet_curr_balance AS balance, COALESCE(SUM(CASE WHEN t.trc_is_debit THEN t.trc_value ELSE -t.trc_value END), 0) AS posted_balance`).
+		Joins(`LEFT JOIN "transaction_table" AS t ON t.wallet_id = w.id`).
+		Where("w.wallet_system_code IS NULL").
+		Group("w.id, w.wallet_curr_balance").
+		Having("w.wallet_curr_balance <> COALESCE(SUM(CASE WHEN t.trc_is_debit THEN t.trc_value ELSE -t.trc_value END), 0)").
+		Order("w.id").
+		Limit(limit).
+		Scan(&drifts).
+		Error
+	return drifts, err
+}
@@ -0,0 +1,511 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// approvalExpiryBatchSize bounds how many requests one ExpireApprovals run expires
+const approvalExpiryBatchSize = 100
+
+// requiresApproval tells whether a transfer of amount in the sender currency has to wait
+// for a second approver, never when the currency has no threshold
+func (s *TransactionServiceImpl) requiresApproval(currency string, amount decimal.Decimal) bool {
+	threshold, ok := s.approvalThresholds[currency]
+	return ok && amount.GreaterThan(threshold)
+}
+
+// queueTransfer checks what can be checked before approval and queues the transfer. The
+// balance and the limits are only checked when it is posted, they may change meanwhile.
+// A quote is held until the approval expires, so the rate the maker saw is the one posted.
+func (s *TransactionServiceImpl) queueTransfer(ctx context.Context, tx *gorm.DB, walletID int64, amount decimal.Decimal, quote *model.FXQuote, req dto.TransferRequest) (dto.TransactionResponse, error) {
+	currency, err := s.walletCurrency(ctx, walletID)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+	receiverCurrency, err := s.walletCurrency(ctx, req.ReceiverWalletID)
+	if errors.Is(err, apperror.ErrWalletNotFound) {
+		return dto.TransactionResponse{}, apperror.ErrWalletNotFound.WithMessage("receiver wallet not found")
+	}
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	if req.QuoteID == nil {
+		if receiverCurrency != currency {
+			return dto.TransactionResponse{}, apperror.ErrCurrencyMismatch
+		}
+		if err := checkPrecision(amount, currency); err != nil {
+			return dto.TransactionResponse{}, err
+		}
+	}
+
+	if quote != nil {
+		// Locked so two transfers can't both queue on the quote
+		locked, err := s.fxQuoteRepo.FindByIDForUpdate(ctx, tx, quote.ID)
+		if err != nil {
+			log.Printf("error fx quote find by id for update, err: %+v", err)
+			return dto.TransactionResponse{}, err
+		}
+		if locked == nil {
+			return dto.TransactionResponse{}, apperror.ErrQuoteNotFound
+		}
+		if locked.ReceiverWalletID != req.ReceiverWalletID {
+			return dto.TransactionResponse{}, apperror.ErrInvalidRequest.WithMessage("quote was issued for another receiver wallet")
+		}
+		if locked.JournalEntryID != nil || locked.ApprovalRequestID != nil {
+			return dto.TransactionResponse{}, apperror.ErrQuoteExpired.WithMessage("quote was already used")
+		}
+		if !locked.ExpiresAt.After(time.Now()) {
+			return dto.TransactionResponse{}, apperror.ErrQuoteExpired
+		}
+		if !req.Amount.IsZero() && !req.Amount.Equal(locked.SourceAmount) {
+			return dto.TransactionResponse{}, apperror.ErrInvalidAmount.WithMessage("amount does not match the quote")
+		}
+	}
+
+	approval, err := s.queueApproval(ctx, tx, constant.ApprovalOperationTransfer, walletID, amount, req)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	if quote != nil {
+		if err := s.fxQuoteRepo.HoldForApproval(ctx, tx, quote.ID, approval.ID, approval.ExpiresAt); err != nil {
+			log.Printf("holding fx quote for approval, err: %+v", err)
+			return dto.TransactionResponse{}, err
+		}
+	}
+
+	return dto.TransactionResponse{
+		ApprovalID:        &approval.ID,
+		ApprovalExpiresAt: &approval.ExpiresAt,
+	}, nil
+}
+
+// reversalApproval is the stored request of a reversal waiting for approval
+type reversalApproval struct {
+	TransactionID int64 `json:"transaction_id"`
+	dto.ReverseRequest
+}
+
+// queueReversal queues the reversal of an adjustment, the amount left to reverse is checked
+// when it is posted
+func (s *TransactionServiceImpl) queueReversal(ctx context.Context, tx *gorm.DB, original *model.Transaction, req dto.ReverseRequest) (dto.ReversalResponse, error) {
+	amount := original.Value
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+
+	approval, err := s.queueApproval(ctx, tx, constant.ApprovalOperationReversal, original.WalletID, amount, reversalApproval{
+		TransactionID:  original.ID,
+		ReverseRequest: req,
+	})
+	if err != nil {
+		return dto.ReversalResponse{}, err
+	}
+
+	return dto.ReversalResponse{
+		TransactionID:     original.ID,
+		Amount:            amount,
+		ApprovalID:        &approval.ID,
+		ApprovalExpiresAt: &approval.ExpiresAt,
+	}, nil
+}
+
+// CreateAdjustment queues a manual balance correction, it is always approved by a second admin
+func (s *TransactionServiceImpl) CreateAdjustment(ctx context.Context, idempotencyKey string, req dto.AdjustmentRequest) (resp dto.ApprovalResponse, statusCode int, err error) {
+	return withIdempotency(ctx, s.db, s.idempotencyStore, req.WalletID, idempotencyOperationAdjust, idempotencyKey, req, respondWith[dto.ApprovalResponse](http.StatusAccepted), func(tx *gorm.DB) (dto.ApprovalResponse, error) {
+		wallet, err := s.walletRepo.FindByID(ctx, req.WalletID)
+		if err != nil {
+			return dto.ApprovalResponse{}, err
+		}
+		if wallet == nil || wallet.IsSystem() {
+			return dto.ApprovalResponse{}, apperror.ErrWalletNotFound
+		}
+		if err := checkPrecision(req.Amount, wallet.Currency); err != nil {
+			return dto.ApprovalResponse{}, err
+		}
+
+		approval, err := s.queueApproval(ctx, tx, constant.ApprovalOperationAdjustment, wallet.ID, req.Amount, req)
+		if err != nil {
+			return dto.ApprovalResponse{}, err
+		}
+		return dto.NewApprovalResponse(*approval), nil
+	})
+}
+
+// queueApproval persists the request of the operation with the caller as its maker, and
+// audits it in the same DB transaction
+func (s *TransactionServiceImpl) queueApproval(ctx context.Context, tx *gorm.DB, operation string, walletID int64, amount decimal.Decimal, req interface{}) (*model.ApprovalRequest, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	approval := &model.ApprovalRequest{
+		Operation: operation,
+		WalletID:  walletID,
+		Amount:    amount,
+		Payload:   string(payload),
+		Status:    constant.ApprovalStatusPending,
+		ExpiresAt: now.Add(s.approvalTTL),
+		CreatedAt: now,
+	}
+	if principal, ok := principalFromContext(ctx); ok {
+		approval.MakerID = principal.ID
+		approval.MakerCustomerID = principal.CustomerID
+	}
+
+	if err := s.approvalRepo.CreateApproval(ctx, tx, approval); err != nil {
+		log.Printf("creating approval request, err: %+v", err)
+		return nil, err
+	}
+	if err := s.auditApproval(ctx, tx, approval.ID, constant.ApprovalActionRequested, ""); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// ListApprovals returns the queue, the pending requests unless another status is asked for
+func (s *TransactionServiceImpl) ListApprovals(ctx context.Context, req dto.ApprovalListRequest) (dto.ApprovalListResponse, error) {
+	status := req.Status
+	if status == "" {
+		status = constant.ApprovalStatusPending
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = dto.DefaultApprovalListLimit
+	}
+
+	approvals, err := s.approvalRepo.GetListApproval(ctx, status, limit)
+	if err != nil {
+		log.Printf("error listing approval requests, err: %+v", err)
+		return dto.ApprovalListResponse{}, err
+	}
+	return dto.NewApprovalListResponse(approvals), nil
+}
+
+func (s *TransactionServiceImpl) GetApproval(ctx context.Context, approvalID int64) (dto.ApprovalResponse, error) {
+	approval, err := s.approvalRepo.FindByID(ctx, approvalID)
+	if err != nil {
+		return dto.ApprovalResponse{}, err
+	}
+	if approval == nil {
+		return dto.ApprovalResponse{}, apperror.ErrApprovalNotFound
+	}
+	return dto.NewApprovalResponse(*approval), nil
+}
+
+// ApproveRequest posts the queued operation on behalf of its maker. The request is locked
+// first, like RejectRequest does, so a concurrent decision waits instead of posting too,
+// and the maker must still be allowed to run the operation.
+func (s *TransactionServiceImpl) ApproveRequest(ctx context.Context, approvalID int64) (dto.ApprovalResponse, error) {
+	var resp dto.ApprovalResponse
+	err := runInTransaction(ctx, s.db, func(tx *gorm.DB) error {
+		approval, err := s.lockPendingApproval(ctx, tx, approvalID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkDecidable(ctx, approval); err != nil {
+			return err
+		}
+		if err := s.checkMakerAccess(ctx, approval); err != nil {
+			return err
+		}
+
+		transactionID, err := s.executeApproval(makerContext(ctx, approval), tx, approval)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		approval.Status = constant.ApprovalStatusApproved
+		approval.CheckerID = actingPrincipalID(ctx)
+		approval.TransactionID = &transactionID
+		approval.DecidedAt = &now
+		if err := s.decideApproval(ctx, tx, approval, constant.ApprovalActionApproved, ""); err != nil {
+			return err
+		}
+
+		resp = dto.NewApprovalResponse(*approval)
+		return nil
+	})
+	return resp, err
+}
+
+// RejectRequest drops the queued operation, nothing was posted for it
+func (s *TransactionServiceImpl) RejectRequest(ctx context.Context, approvalID int64, req dto.RejectApprovalRequest) (dto.ApprovalResponse, error) {
+	var resp dto.ApprovalResponse
+	err := runInTransaction(ctx, s.db, func(tx *gorm.DB) error {
+		approval, err := s.lockPendingApproval(ctx, tx, approvalID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkDecidable(ctx, approval); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		approval.Status = constant.ApprovalStatusRejected
+		approval.CheckerID = actingPrincipalID(ctx)
+		approval.DecidedAt = &now
+		reason := strings.TrimSpace(req.Reason)
+		if reason != "" {
+			approval.DecisionReason = &reason
+		}
+		if err := s.decideApproval(ctx, tx, approval, constant.ApprovalActionRejected, reason); err != nil {
+			return err
+		}
+
+		resp = dto.NewApprovalResponse(*approval)
+		return nil
+	})
+	return resp, err
+}
+
+// ExpireApprovals expires pending requests past their expiry, one DB transaction per
+// request. It returns how many were expired.
+func (s *TransactionServiceImpl) ExpireApprovals(ctx context.Context) (int, error) {
+	approvals, err := s.approvalRepo.GetListExpiredApproval(ctx, time.Now(), approvalExpiryBatchSize)
+	if err != nil {
+		log.Printf("error listing expired approval requests, err: %+v", err)
+		return 0, err
+	}
+
+	expired := 0
+	for _, candidate := range approvals {
+		decided := false
+		err := runInTransaction(ctx, s.db, func(tx *gorm.DB) error {
+			decided = false
+
+			approval, err := s.approvalRepo.FindByIDForUpdate(ctx, tx, candidate.ID)
+			if err != nil {
+				return err
+			}
+			if approval == nil || approval.Status != constant.ApprovalStatusPending || approval.ExpiresAt.After(time.Now()) {
+				return nil
+			}
+
+			now := time.Now()
+			approval.Status = constant.ApprovalStatusExpired
+			approval.DecidedAt = &now
+			err = s.decideApproval(ctx, tx, approval, constant.ApprovalActionExpired, "")
+			decided = err == nil
+			return err
+		})
+		if err != nil {
+			log.Printf("error expiring approval request, approval: %d, err: %+v", candidate.ID, err)
+			continue
+		}
+		if decided {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// checkDecidable keeps the maker from deciding on its own request, and a request past its
+// expiry from being decided before the job gets to it
+func (s *TransactionServiceImpl) checkDecidable(ctx context.Context, approval *model.ApprovalRequest) error {
+	if approval == nil {
+		return apperror.ErrApprovalNotFound
+	}
+	if approval.Status != constant.ApprovalStatusPending {
+		return apperror.ErrApprovalNotPending
+	}
+	if !approval.ExpiresAt.After(time.Now()) {
+		return apperror.ErrApprovalNotPending.WithMessage("approval request has expired")
+	}
+
+	// The checker must be known to be told apart from the maker
+	principal, ok := principalFromContext(ctx)
+	if !ok || principal.ID == 0 {
+		return apperror.ErrForbidden.WithMessage("only an authenticated admin can decide on a request")
+	}
+	if principal.ID == approval.MakerID {
+		return apperror.ErrSelfApproval
+	}
+	return nil
+}
+
+func (s *TransactionServiceImpl) lockPendingApproval(ctx context.Context, tx *gorm.DB, approvalID int64) (*model.ApprovalRequest, error) {
+	approval, err := s.approvalRepo.FindByIDForUpdate(ctx, tx, approvalID)
+	if err != nil {
+		log.Printf("error approval find by id for update, err: %+v", err)
+		return nil, err
+	}
+	if approval == nil {
+		return nil, apperror.ErrApprovalNotFound
+	}
+	if approval.Status != constant.ApprovalStatusPending {
+		return nil, apperror.ErrApprovalNotPending
+	}
+	return approval, nil
+}
+
+// decideApproval stores the decision and audits it, the caller must hold the request lock
+func (s *TransactionServiceImpl) decideApproval(ctx context.Context, tx *gorm.DB, approval *model.ApprovalRequest, action string, remarks string) error {
+	if err := s.approvalRepo.UpdateApproval(ctx, tx, *approval); err != nil {
+		log.Printf("updating approval request, err: %+v", err)
+		return err
+	}
+	return s.auditApproval(ctx, tx, approval.ID, action, remarks)
+}
+
+// auditApproval appends to the audit log, the principal is empty for the expiry job
+func (s *TransactionServiceImpl) auditApproval(ctx context.Context, tx *gorm.DB, approvalID int64, action string, remarks string) error {
+	err := s.approvalRepo.CreateAudit(ctx, tx, model.ApprovalAudit{
+		ApprovalRequestID: approvalID,
+		Action:            action,
+		PrincipalID:       actingPrincipalID(ctx),
+		Remarks:           remarks,
+		CreatedAt:         time.Now(),
+	})
+	if err != nil {
+		log.Printf("creating approval audit, err: %+v", err)
+	}
+	return err
+}
+
+// executeApproval posts the operation from its stored request and returns its transaction ID
+func (s *TransactionServiceImpl) executeApproval(ctx context.Context, tx *gorm.DB, approval *model.ApprovalRequest) (int64, error) {
+	switch approval.Operation {
+	case constant.ApprovalOperationTransfer:
+		var req dto.TransferRequest
+		if err := json.Unmarshal([]byte(approval.Payload), &req); err != nil {
+			return 0, err
+		}
+		resp, err := s.postTransfer(ctx, tx, approval.WalletID, req)
+		if err != nil {
+			return 0, err
+		}
+		return resp.TransactionID, nil
+	case constant.ApprovalOperationAdjustment:
+		var req dto.AdjustmentRequest
+		if err := json.Unmarshal([]byte(approval.Payload), &req); err != nil {
+			return 0, err
+		}
+		return s.postAdjustment(ctx, tx, req)
+	case constant.ApprovalOperationReversal:
+		var req reversalApproval
+		if err := json.Unmarshal([]byte(approval.Payload), &req); err != nil {
+			return 0, err
+		}
+		original, err := s.reversibleTransaction(ctx, req.TransactionID, req.ReverseRequest)
+		if err != nil {
+			return 0, err
+		}
+		_, transactionID, err := s.postReversal(ctx, tx, original, req.ReverseRequest)
+		return transactionID, err
+	default:
+		log.Printf("unknown approval operation, approval: %d, operation: %s", approval.ID, approval.Operation)
+		return 0, apperror.ErrInternal
+	}
+}
+
+// postAdjustment moves the amount between the wallet and the adjustments system wallet, a
+// debit can't take the wallet below its available balance
+func (s *TransactionServiceImpl) postAdjustment(ctx context.Context, tx *gorm.DB, req dto.AdjustmentRequest) (int64, error) {
+	currency, err := s.walletCurrency(ctx, req.WalletID)
+	if err != nil {
+		return 0, err
+	}
+
+	adjustmentsID, err := s.systemWalletID(ctx, constant.SystemWalletAdjustments, currency)
+	if err != nil {
+		return 0, err
+	}
+
+	wallets, err := s.lockWallets(ctx, tx, req.WalletID, adjustmentsID)
+	if err != nil {
+		return 0, err
+	}
+
+	wallet := customerWallet(wallets, req.WalletID)
+	if wallet == nil {
+		return 0, apperror.ErrWalletNotFound
+	}
+
+	amount := req.Amount.Abs()
+	credit := req.Amount.IsPositive()
+	if !credit && amount.GreaterThan(wallet.AvailableBalance()) {
+		log.Printf("attempting to adjust more than available balance, wallet: %d", wallet.ID)
+		return 0, apperror.ErrInsufficientFunds
+	}
+
+	remarks := "Adjustment - " + strings.TrimSpace(req.Reason)
+	_, transactionIDs, err := s.postJournalEntry(ctx, tx, model.JournalEntry{
+		Type:    constant.TransactionTypeAdjustment,
+		Amount:  amount,
+		Remarks: remarks,
+	}, []ledgerLeg{
+		{Wallet: wallet, Counterparty: wallets[adjustmentsID], IsDebit: credit, Amount: amount, Remarks: remarks},
+		{Wallet: wallets[adjustmentsID], Counterparty: wallet, IsDebit: !credit, Amount: amount, Remarks: remarks},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return transactionIDs[0], nil
+}
+
+// checkMakerAccess reads the roles of the maker again, a maker that lost its spender role
+// on the wallet, or its admin rights for an admin operation, can't have it run anymore.
+// A request made in the insecure header mode has no maker to check.
+func (s *TransactionServiceImpl) checkMakerAccess(ctx context.Context, approval *model.ApprovalRequest) error {
+	if approval.MakerID == 0 {
+		return nil
+	}
+
+	principal, err := s.principalRepo.FindByID(ctx, approval.MakerID)
+	if err != nil {
+		log.Printf("error finding principal by id, err: %+v", err)
+		return err
+	}
+	if principal == nil {
+		return apperror.ErrForbidden.WithMessage("the maker of the request no longer exists")
+	}
+	maker, err := loadPrincipal(ctx, s.principalRepo, s.memberRepo, *principal)
+	if err != nil {
+		return err
+	}
+
+	allowed := maker.IsAdmin
+	if approval.Operation == constant.ApprovalOperationTransfer {
+		allowed = maker.CanAccessWallet(approval.WalletID, constant.WalletRoleSpender)
+	}
+	if !allowed {
+		return apperror.ErrForbidden.WithMessage("the maker of the request is no longer allowed to run it")
+	}
+	return nil
+}
+
+// makerContext swaps the checker for the maker, so the posting records the maker as its
+// initiator and the spend limit of the maker applies
+func makerContext(ctx context.Context, approval *model.ApprovalRequest) context.Context {
+	if approval.MakerID == 0 {
+		return ContextWithPrincipal(ctx, Principal{Unrestricted: true})
+	}
+	return ContextWithPrincipal(ctx, Principal{ID: approval.MakerID, CustomerID: approval.MakerCustomerID})
+}
+
+// actingPrincipalID is the caller acting on a request, nil for a job or the insecure header mode
+func actingPrincipalID(ctx context.Context) *int64 {
+	principal, ok := principalFromContext(ctx)
+	if !ok || principal.ID == 0 {
+		return nil
+	}
+	return &principal.ID
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/signature"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/token"
+)
+
+// SignedRequest is what a request signature covers, Path includes the query string
+type SignedRequest struct {
+	Method   string
+	Path     string
+	BodyHash string
+	Params   signature.Params
+}
+
+type AuthService interface {
+	AuthenticateAPIKey(ctx context.Context, apiKey string) (Principal, error)
+	AuthenticateToken(ctx context.Context, bearerToken string) (Principal, error)
+	AuthenticateSignature(ctx context.Context, req SignedRequest) (Principal, error)
+}
+
+type AuthServiceImpl struct {
+	principalRepo    repository.PrincipalRepository
+	memberRepo       repository.WalletMemberRepository
+	nonceStore       repository.NonceStore
+	jwtSecret        []byte
+	signatureMaxSkew time.Duration
+}
+
+// NewAuthService takes a nil nonceStore when request signing is off
+func NewAuthService(principalRepo repository.PrincipalRepository, memberRepo repository.WalletMemberRepository, nonceStore repository.NonceStore, jwtSecret string, signatureMaxSkew time.Duration) AuthService {
+	return &AuthServiceImpl{
+		principalRepo:    principalRepo,
+		memberRepo:       memberRepo,
+		nonceStore:       nonceStore,
+		jwtSecret:        []byte(jwtSecret),
+		signatureMaxSkew: signatureMaxSkew,
+	}
+}
+
+// AuthenticateAPIKey resolves a key that is not revoked to its principal
+func (s *AuthServiceImpl) AuthenticateAPIKey(ctx context.Context, apiKey string) (Principal, error) {
+	sum := sha256.Sum256([]byte(apiKey))
+	principal, err := s.principalRepo.FindByAPIKeyHash(ctx, hex.EncodeToString(sum[:]))
+	if err != nil {
+		log.Printf("error finding principal by api key, err: %+v", err)
+		return Principal{}, err
+	}
+	if principal == nil {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("invalid api key")
+	}
+	return loadPrincipal(ctx, s.principalRepo, s.memberRepo, *principal)
+}
+
+// AuthenticateToken verifies an HS256 JWT whose subject is the principal ID. The wallets
+// are read from the database, so unlinking a wallet applies to tokens already issued.
+func (s *AuthServiceImpl) AuthenticateToken(ctx context.Context, bearerToken string) (Principal, error) {
+	if len(s.jwtSecret) == 0 {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("bearer tokens are not enabled")
+	}
+
+	claims, err := token.VerifyHS256(bearerToken, s.jwtSecret, time.Now())
+	if err != nil {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage(err.Error())
+	}
+
+	principalID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("invalid token subject")
+	}
+
+	principal, err := s.principalRepo.FindByID(ctx, principalID)
+	if err != nil {
+		log.Printf("error finding principal by id, err: %+v", err)
+		return Principal{}, err
+	}
+	if principal == nil {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("invalid token subject")
+	}
+	return loadPrincipal(ctx, s.principalRepo, s.memberRepo, *principal)
+}
+
+// AuthenticateSignature verifies an HMAC signed request of a signing client. The nonce is
+// only claimed once the signature is valid, so a forged request can't burn a real nonce,
+// and it is kept for twice the skew, longer than its timestamp stays acceptable.
+func (s *AuthServiceImpl) AuthenticateSignature(ctx context.Context, req SignedRequest) (Principal, error) {
+	if s.nonceStore == nil {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("request signing is not enabled")
+	}
+
+	skew := time.Since(time.Unix(req.Params.Timestamp, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > s.signatureMaxSkew {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("request timestamp is too far from the server time")
+	}
+
+	client, err := s.principalRepo.FindSigningClient(ctx, req.Params.ClientID)
+	if err != nil {
+		log.Printf("error finding signing client, err: %+v", err)
+		return Principal{}, err
+	}
+	if client == nil {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("invalid signature")
+	}
+
+	stringToSign := signature.StringToSign(req.Method, req.Path, req.BodyHash, req.Params.Timestamp, req.Params.Nonce)
+	if !signature.Verify([]byte(client.Secret), stringToSign, req.Params.Signature) {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("invalid signature")
+	}
+
+	claimed, err := s.nonceStore.Claim(ctx, fmt.Sprintf("signature-nonce:%s:%s", client.ClientID, req.Params.Nonce), 2*s.signatureMaxSkew)
+	if err != nil {
+		log.Printf("error claiming signature nonce, err: %+v", err)
+		return Principal{}, err
+	}
+	if !claimed {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("nonce was already used")
+	}
+
+	principal, err := s.principalRepo.FindByID(ctx, client.PrincipalID)
+	if err != nil {
+		log.Printf("error finding principal by id, err: %+v", err)
+		return Principal{}, err
+	}
+	if principal == nil {
+		return Principal{}, apperror.ErrUnauthorized.WithMessage("invalid signature")
+	}
+	return loadPrincipal(ctx, s.principalRepo, s.memberRepo, *principal)
+}
+
+// loadPrincipal collects the wallet roles of the principal. Wallets linked to the principal
+// itself are owned, the others come from the memberships of its customer.
+func loadPrincipal(ctx context.Context, principalRepo repository.PrincipalRepository, memberRepo repository.WalletMemberRepository, principal model.Principal) (Principal, error) {
+	walletIDs, err := principalRepo.GetListWalletID(ctx, principal.ID)
+	if err != nil {
+		log.Printf("error listing principal wallets, err: %+v", err)
+		return Principal{}, err
+	}
+
+	walletRoles := make(map[int64]string, len(walletIDs))
+	for _, walletID := range walletIDs {
+		walletRoles[walletID] = constant.WalletRoleOwner
+	}
+
+	if principal.CustomerID != nil {
+		members, err := memberRepo.GetListByCustomerID(ctx, *principal.CustomerID)
+		if err != nil {
+			log.Printf("error listing wallet memberships, err: %+v", err)
+			return Principal{}, err
+		}
+		for _, member := range members {
+			if _, ok := walletRoles[member.WalletID]; !ok {
+				walletRoles[member.WalletID] = member.Role
+			}
+		}
+	}
+
+	return Principal{
+		ID:          principal.ID,
+		Name:        principal.Name,
+		IsAdmin:     principal.IsAdmin,
+		CustomerID:  principal.CustomerID,
+		WalletRoles: walletRoles,
+	}, nil
+}
@@ -0,0 +1,216 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/signature"
+)
+
+const (
+	testClientID     = "client-1"
+	testClientSecret = "client-secret"
+	testPrincipalID  = 7
+	testWalletID     = 42
+	testMaxSkew      = 5 * time.Minute
+)
+
+// fakePrincipalRepository knows one principal, owning one wallet, with one signing client
+type fakePrincipalRepository struct {
+	repository.PrincipalRepository
+}
+
+func (fakePrincipalRepository) FindByID(ctx context.Context, id int64) (*model.Principal, error) {
+	if id != testPrincipalID {
+		return nil, nil
+	}
+	return &model.Principal{ID: testPrincipalID, Name: "signer"}, nil
+}
+
+func (fakePrincipalRepository) FindSigningClient(ctx context.Context, clientID string) (*model.SigningClient, error) {
+	if clientID != testClientID {
+		return nil, nil
+	}
+	return &model.SigningClient{PrincipalID: testPrincipalID, ClientID: testClientID, Secret: testClientSecret}, nil
+}
+
+func (fakePrincipalRepository) GetListWalletID(ctx context.Context, principalID int64) ([]int64, error) {
+	return []int64{testWalletID}, nil
+}
+
+// fakeNonceStore claims nonces in memory, ttl is not enforced
+type fakeNonceStore struct {
+	mu     sync.Mutex
+	claims map[string]bool
+}
+
+func newFakeNonceStore() *fakeNonceStore {
+	return &fakeNonceStore{claims: map[string]bool{}}
+}
+
+func (s *fakeNonceStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claims[key] {
+		return false, nil
+	}
+	s.claims[key] = true
+	return true, nil
+}
+
+func (s *fakeNonceStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.claims)
+}
+
+func newSignedRequest(method string, path string, body []byte, timestamp time.Time, nonce string) service.SignedRequest {
+	bodyHash := signature.BodyHash(body)
+	return service.SignedRequest{
+		Method:   method,
+		Path:     path,
+		BodyHash: bodyHash,
+		Params: signature.Params{
+			ClientID:  testClientID,
+			Timestamp: timestamp.Unix(),
+			Nonce:     nonce,
+			Signature: signature.Sign([]byte(testClientSecret), signature.StringToSign(method, path, bodyHash, timestamp.Unix(), nonce)),
+		},
+	}
+}
+
+func TestAuthenticateSignature(t *testing.T) {
+	body := []byte(`{"amount":"10"}`)
+
+	tests := []struct {
+		name   string
+		mutate func(req *service.SignedRequest)
+		now    time.Time
+		ok     bool
+	}{
+		{name: "valid", mutate: func(*service.SignedRequest) {}, ok: true},
+		{
+			name: "tampered body",
+			mutate: func(req *service.SignedRequest) {
+				req.BodyHash = signature.BodyHash([]byte(`{"amount":"1000"}`))
+			},
+		},
+		{
+			name:   "tampered path",
+			mutate: func(req *service.SignedRequest) { req.Path = "/api/v1/transfer" },
+		},
+		{
+			name:   "tampered method",
+			mutate: func(req *service.SignedRequest) { req.Method = "DELETE" },
+		},
+		{
+			name:   "unknown client",
+			mutate: func(req *service.SignedRequest) { req.Params.ClientID = "client-2" },
+		},
+		{
+			name:   "timestamp too old",
+			mutate: func(*service.SignedRequest) {},
+			now:    time.Now().Add(-testMaxSkew - time.Minute),
+		},
+		{
+			name:   "timestamp in the future",
+			mutate: func(*service.SignedRequest) {},
+			now:    time.Now().Add(testMaxSkew + time.Minute),
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nonces := newFakeNonceStore()
+			auth := service.NewAuthService(fakePrincipalRepository{}, nil, nonces, "", testMaxSkew)
+
+			now := tt.now
+			if now.IsZero() {
+				now = time.Now()
+			}
+			req := newSignedRequest("POST", "/api/v1/withdraw", body, now, "nonce-"+strconv.Itoa(i))
+			tt.mutate(&req)
+
+			principal, err := auth.AuthenticateSignature(context.Background(), req)
+			if !tt.ok {
+				if !errors.Is(err, apperror.ErrUnauthorized) {
+					t.Fatalf("err = %v, want ErrUnauthorized", err)
+				}
+				// A rejected request must not burn the nonce of the real one
+				if nonces.count() != 0 {
+					t.Errorf("nonce was claimed by a rejected request")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if principal.ID != testPrincipalID {
+				t.Errorf("principal = %d, want %d", principal.ID, testPrincipalID)
+			}
+			if !principal.CanAccessWallet(testWalletID, constant.WalletRoleOwner) {
+				t.Errorf("principal can't access its own wallet")
+			}
+		})
+	}
+}
+
+func TestAuthenticateSignatureRejectsReplayedNonce(t *testing.T) {
+	auth := service.NewAuthService(fakePrincipalRepository{}, nil, newFakeNonceStore(), "", testMaxSkew)
+	req := newSignedRequest("POST", "/api/v1/withdraw", []byte(`{"amount":"10"}`), time.Now(), "nonce-1")
+
+	if _, err := auth.AuthenticateSignature(context.Background(), req); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	_, err := auth.AuthenticateSignature(context.Background(), req)
+	if !errors.Is(err, apperror.ErrUnauthorized) {
+		t.Fatalf("replayed request err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestAuthenticateSignatureConcurrentReplay(t *testing.T) {
+	auth := service.NewAuthService(fakePrincipalRepository{}, nil, newFakeNonceStore(), "", testMaxSkew)
+	req := newSignedRequest("POST", "/api/v1/withdraw", []byte(`{"amount":"10"}`), time.Now(), "nonce-1")
+
+	const attempts = 50
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := auth.AuthenticateSignature(context.Background(), req); err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Errorf("accepted %d requests with one nonce, want 1", accepted)
+	}
+}
+
+func TestAuthenticateSignatureDisabled(t *testing.T) {
+	auth := service.NewAuthService(fakePrincipalRepository{}, nil, nil, "", testMaxSkew)
+	req := newSignedRequest("POST", "/api/v1/withdraw", nil, time.Now(), "nonce-1")
+
+	_, err := auth.AuthenticateSignature(context.Background(), req)
+	if !errors.Is(err, apperror.ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// depositClearingBatchSize bounds how many deposits one ClearDeposits run releases
+const depositClearingBatchSize = 100
+
+// startDepositClearing keeps a fresh deposit out of the available balance until the
+// clearing delay has passed. It returns when the deposit clears, nil when it is
+// available straight away.
+func (s *TransactionServiceImpl) startDepositClearing(ctx context.Context, tx *gorm.DB, wallet *model.Wallet, journalEntryID int64, amount decimal.Decimal) (*time.Time, error) {
+	if s.depositClearingDelay <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	clearing := model.DepositClearing{
+		WalletID:       wallet.ID,
+		JournalEntryID: journalEntryID,
+		Amount:         amount,
+		ClearsAt:       now.Add(s.depositClearingDelay),
+		CreatedAt:      now,
+	}
+	err := s.clearingRepo.CreateDepositClearing(ctx, tx, &clearing)
+	if err != nil {
+		log.Printf("creating deposit clearing, err: %+v", err)
+		return nil, err
+	}
+
+	wallet.UnclearedBalance = wallet.UnclearedBalance.Add(amount)
+	err = s.walletRepo.UpdateUnclearedBalance(ctx, tx, wallet.ID, wallet.UnclearedBalance)
+	if err != nil {
+		log.Printf("updating uncleared balance, err: %+v", err)
+		return nil, err
+	}
+
+	return &clearing.ClearsAt, nil
+}
+
+// reduceDepositClearing takes a reversed deposit out of its clearing first, so money that
+// was never available can still be clawed back. wallets must hold the locked deposit wallet.
+func (s *TransactionServiceImpl) reduceDepositClearing(ctx context.Context, tx *gorm.DB, wallets map[int64]*model.Wallet, journalEntryID int64, amount decimal.Decimal) error {
+	clearing, err := s.clearingRepo.FindPendingByJournalEntryIDForUpdate(ctx, tx, journalEntryID)
+	if err != nil {
+		log.Printf("error deposit clearing find by journal entry id, err: %+v", err)
+		return err
+	}
+	if clearing == nil {
+		return nil
+	}
+
+	wallet := wallets[clearing.WalletID]
+	if wallet == nil {
+		return nil
+	}
+
+	released := decimal.Min(amount, clearing.Amount)
+	clearing.Amount = clearing.Amount.Sub(released)
+	if clearing.Amount.IsZero() {
+		now := time.Now()
+		clearing.ClearedAt = &now
+	}
+	err = s.clearingRepo.UpdateDepositClearing(ctx, tx, *clearing)
+	if err != nil {
+		log.Printf("updating deposit clearing, err: %+v", err)
+		return err
+	}
+
+	wallet.UnclearedBalance = wallet.UnclearedBalance.Sub(released)
+	err = s.walletRepo.UpdateUnclearedBalance(ctx, tx, wallet.ID, wallet.UnclearedBalance)
+	if err != nil {
+		log.Printf("updating uncleared balance, err: %+v", err)
+		return err
+	}
+	return nil
+}
+
+// ClearDeposits makes deposits past their clearing delay available, one DB transaction per
+// deposit so a failure only leaves that deposit for the next run. It returns how many cleared.
+func (s *TransactionServiceImpl) ClearDeposits(ctx context.Context) (int, error) {
+	clearings, err := s.clearingRepo.GetListDueDepositClearing(ctx, time.Now(), depositClearingBatchSize)
+	if err != nil {
+		log.Printf("error listing due deposit clearings, err: %+v", err)
+		return 0, err
+	}
+
+	cleared := 0
+	for _, candidate := range clearings {
+		released := false
+		err := runInTransaction(ctx, s.db, func(tx *gorm.DB) error {
+			released = false
+
+			// Wallet first, then clearing, the same order a reversal locks in
+			wallet, err := s.walletRepo.FindByIDForUpdate(ctx, tx, candidate.WalletID)
+			if err != nil {
+				return err
+			}
+
+			clearing, err := s.clearingRepo.FindByIDForUpdate(ctx, tx, candidate.ID)
+			if err != nil {
+				return err
+			}
+			if clearing == nil || clearing.ClearedAt != nil {
+				return nil
+			}
+
+			now := time.Now()
+			clearing.ClearedAt = &now
+			if err := s.clearingRepo.UpdateDepositClearing(ctx, tx, *clearing); err != nil {
+				return err
+			}
+
+			// A closed wallet has nothing uncleared left to release
+			if wallet != nil {
+				err = s.walletRepo.UpdateUnclearedBalance(ctx, tx, wallet.ID, wallet.UnclearedBalance.Sub(clearing.Amount))
+				if err != nil {
+					return err
+				}
+			}
+
+			released = true
+			return nil
+		})
+		if err != nil {
+			log.Printf("error clearing deposit, clearing: %d, err: %+v", candidate.ID, err)
+			continue
+		}
+		if released {
+			cleared++
+		}
+	}
+	return cleared, nil
+}
@@ -0,0 +1,245 @@
+//go:build integration
+
+// These tests need a Postgres database and are left out of a plain go test ./... by the
+// integration build tag. Apply the migrations with golang-migrate, then run them with the tag:
+//
+//	migrate -path migrations -database "$TEST_DATABASE_URL" up
+//	TEST_DATABASE_URL=postgresql://... go test -race -tags integration ./internal/domain/service/
+//
+// Without TEST_DATABASE_URL every test here is skipped.
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/configs"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/shopspring/decimal"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newIntegrationService(t *testing.T) (service.Service, *gorm.DB) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connecting to database: %v", err)
+	}
+
+	config := &configs.Config{
+		DatabaseURL:      dsn,
+		IdempotencyStore: repository.IdempotencyStorePostgres,
+		IdempotencyTTL:   time.Hour,
+		HoldDefaultTTL:   time.Hour,
+		FXQuoteTTL:       30 * time.Second,
+		ApprovalTTL:      time.Hour,
+	}
+	repo, err := repository.New(db, nil, config)
+	if err != nil {
+		t.Fatalf("creating repository: %v", err)
+	}
+	svc, err := service.New(repo, db, config)
+	if err != nil {
+		t.Fatalf("creating service: %v", err)
+	}
+	return svc, db
+}
+
+func createFundedWallet(t *testing.T, svc service.Service, name string, balance decimal.Decimal) int64 {
+	t.Helper()
+	ctx := context.Background()
+
+	wallet, err := svc.Wallet.CreateWallet(ctx, service.Principal{}, dto.CreateWalletRequest{Name: name})
+	if err != nil {
+		t.Fatalf("creating wallet: %v", err)
+	}
+	if balance.IsPositive() {
+		key := fmt.Sprintf("%s-funding-%d", t.Name(), time.Now().UnixNano())
+		_, _, err := svc.Transaction.Deposit(ctx, key, wallet.WalletID, dto.AmountRequest{Amount: balance})
+		if err != nil {
+			t.Fatalf("funding wallet: %v", err)
+		}
+	}
+	return wallet.WalletID
+}
+
+// TestConcurrentWithdrawAndTransfer drains one wallet from hundreds of goroutines. Every
+// request either posts in full or is turned away, and the wallet never goes below zero.
+func TestConcurrentWithdrawAndTransfer(t *testing.T) {
+	svc, db := newIntegrationService(t)
+	ctx := context.Background()
+
+	initial := decimal.NewFromInt(1000)
+	amount := decimal.NewFromInt(7)
+	senderID := createFundedWallet(t, svc, "concurrency sender", initial)
+	receiverID := createFundedWallet(t, svc, "concurrency receiver", decimal.Zero)
+
+	const requests = 300
+	var (
+		mu        sync.Mutex
+		succeeded int
+		spent     = decimal.Zero
+		received  = decimal.Zero
+		maxFee    = decimal.Zero
+		wg        sync.WaitGroup
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			key := fmt.Sprintf("%s-%d-%d", t.Name(), senderID, i)
+			var (
+				resp dto.TransactionResponse
+				err  error
+			)
+			if i%2 == 0 {
+				resp, _, err = svc.Transaction.Withdraw(ctx, key, senderID, dto.AmountRequest{Amount: amount})
+			} else {
+				resp, _, err = svc.Transaction.Transfer(ctx, key, senderID, dto.TransferRequest{ReceiverWalletID: receiverID, Amount: amount})
+			}
+
+			if errors.Is(err, apperror.ErrInsufficientFunds) || errors.Is(err, apperror.ErrLimitExceeded) || errors.Is(err, apperror.ErrTransactionConflict) {
+				return
+			}
+			if err != nil {
+				t.Errorf("request %d: %v", i, err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			succeeded++
+			spent = spent.Add(amount)
+			if resp.Fee != nil {
+				spent = spent.Add(*resp.Fee)
+				maxFee = decimal.Max(maxFee, *resp.Fee)
+			}
+			if i%2 == 1 {
+				received = received.Add(amount)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The requests ask for more than the wallet holds, so some must post and the rest find it drained
+	if succeeded == 0 {
+		t.Fatalf("no request succeeded")
+	}
+	if succeeded == requests {
+		t.Fatalf("all %d requests succeeded on a wallet that cannot cover them", requests)
+	}
+
+	sender, err := svc.Wallet.WalletBalance(ctx, senderID)
+	if err != nil {
+		t.Fatalf("reading sender balance: %v", err)
+	}
+	if floor := amount.Add(maxFee); !sender.LedgerBalance.LessThan(floor) {
+		t.Errorf("sender balance = %s after %d of %d requests succeeded, want below %s", sender.LedgerBalance, succeeded, requests, floor)
+	}
+	if want := initial.Sub(spent); !sender.LedgerBalance.Equal(want) {
+		t.Errorf("sender balance = %s, want %s", sender.LedgerBalance, want)
+	}
+	if sender.LedgerBalance.IsNegative() {
+		t.Errorf("sender balance went negative: %s", sender.LedgerBalance)
+	}
+
+	receiver, err := svc.Wallet.WalletBalance(ctx, receiverID)
+	if err != nil {
+		t.Fatalf("reading receiver balance: %v", err)
+	}
+	if !receiver.LedgerBalance.Equal(received) {
+		t.Errorf("receiver balance = %s, want %s", receiver.LedgerBalance, received)
+	}
+
+	// Every posting stores the balance it left behind, none of them may be below zero
+	var lowest decimal.NullDecimal
+	err = db.Model(&model.Transaction{}).
+		Select("MIN(trc_balance_after)").
+		Where("wallet_id = ?", senderID).
+		Scan(&lowest).
+		Error
+	if err != nil {
+		t.Fatalf("reading lowest balance: %v", err)
+	}
+	if lowest.Valid && lowest.Decimal.IsNegative() {
+		t.Errorf("sender balance went negative during the run: %s", lowest.Decimal)
+	}
+	drifted, err := svc.Transaction.ReconcileBalances(ctx)
+	if err != nil {
+		t.Fatalf("reconciling balances: %v", err)
+	}
+	if drifted != 0 {
+		t.Errorf("%d wallets have a balance that differs from their postings", drifted)
+	}
+}
+
+// TestConcurrentDuplicateWithdraw sends one idempotency key from many goroutines. The
+// withdrawal posts once and every duplicate replays its response.
+func TestConcurrentDuplicateWithdraw(t *testing.T) {
+	svc, db := newIntegrationService(t)
+	ctx := context.Background()
+
+	amount := decimal.NewFromInt(10)
+	walletID := createFundedWallet(t, svc, "duplicate sender", decimal.NewFromInt(1000))
+	key := fmt.Sprintf("%s-%d", t.Name(), walletID)
+
+	const requests = 50
+	var (
+		wg        sync.WaitGroup
+		responses [requests]dto.TransactionResponse
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			resp, statusCode, err := svc.Transaction.Withdraw(ctx, key, walletID, dto.AmountRequest{Amount: amount})
+			if err != nil {
+				t.Errorf("request %d: %v", i, err)
+				return
+			}
+			if statusCode != 200 {
+				t.Errorf("request %d: status = %d, want 200", i, statusCode)
+			}
+			responses[i] = resp
+		}(i)
+	}
+	wg.Wait()
+
+	for i, resp := range responses {
+		if resp.TransactionID != responses[0].TransactionID {
+			t.Errorf("request %d replayed transaction %d, want %d", i, resp.TransactionID, responses[0].TransactionID)
+		}
+	}
+
+	var postings int64
+	err := db.Model(&model.Transaction{}).
+		Where("wallet_id = ? AND trc_type = ? AND NOT trc_is_fee", walletID, constant.TransactionTypeWithdraw).
+		Count(&postings).
+		Error
+	if err != nil {
+		t.Fatalf("counting withdrawals: %v", err)
+	}
+	if postings != 1 {
+		t.Errorf("posted %d withdrawals, want 1", postings)
+	}
+}
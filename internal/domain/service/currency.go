@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/shopspring/decimal"
+)
+
+// checkPrecision rejects amounts with more decimals than the currency's minor unit
+func checkPrecision(amount decimal.Decimal, currency string) error {
+	units, ok := constant.CurrencyMinorUnits(currency)
+	if !ok {
+		return fmt.Errorf("currency %s is not supported", currency)
+	}
+	if !amount.Equal(amount.Truncate(units)) {
+		return apperror.ErrInvalidAmount.WithMessage(fmt.Sprintf("%s amounts allow at most %d decimals", currency, units))
+	}
+	return nil
+}
+
+// walletCurrency reads the currency of an open customer wallet before it is locked, the
+// currency never changes so the unlocked read is safe
+func (s *TransactionServiceImpl) walletCurrency(ctx context.Context, walletID int64) (string, error) {
+	wallet, err := s.walletRepo.FindByID(ctx, walletID)
+	if err != nil {
+		return "", err
+	}
+	if wallet == nil {
+		return "", apperror.ErrWalletNotFound
+	}
+	return wallet.Currency, nil
+}
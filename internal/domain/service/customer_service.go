@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+)
+
+type CustomerService interface {
+	CreateCustomer(ctx context.Context, req dto.CreateCustomerRequest) (dto.CustomerResponse, error)
+	GetCustomer(ctx context.Context, id int64) (dto.CustomerResponse, error)
+	UpdateCustomer(ctx context.Context, id int64, req dto.UpdateCustomerRequest) (dto.CustomerResponse, error)
+	ListCustomerWallets(ctx context.Context, id int64) (dto.CustomerWalletsResponse, error)
+}
+
+type CustomerServiceImpl struct {
+	customerRepo repository.CustomerRepository
+	walletRepo   repository.WalletRepository
+	memberRepo   repository.WalletMemberRepository
+}
+
+func NewCustomerService(customerRepo repository.CustomerRepository, walletRepo repository.WalletRepository, memberRepo repository.WalletMemberRepository) CustomerService {
+	return &CustomerServiceImpl{customerRepo: customerRepo, walletRepo: walletRepo, memberRepo: memberRepo}
+}
+
+func (s *CustomerServiceImpl) CreateCustomer(ctx context.Context, req dto.CreateCustomerRequest) (dto.CustomerResponse, error) {
+	now := time.Now()
+	customer := model.Customer{
+		Name:      strings.TrimSpace(req.Name),
+		Email:     strings.ToLower(req.Email),
+		Phone:     req.Phone,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := s.customerRepo.CreateCustomer(ctx, &customer)
+	if errors.Is(err, repository.ErrCustomerEmailTaken) {
+		return dto.CustomerResponse{}, apperror.ErrCustomerEmailTaken
+	}
+	if err != nil {
+		log.Printf("error creating customer, err: %+v", err)
+		return dto.CustomerResponse{}, err
+	}
+
+	return dto.NewCustomerResponse(customer), nil
+}
+
+func (s *CustomerServiceImpl) GetCustomer(ctx context.Context, id int64) (dto.CustomerResponse, error) {
+	customer, err := s.customerRepo.FindByID(ctx, id)
+	if err != nil {
+		return dto.CustomerResponse{}, err
+	}
+	if customer == nil {
+		return dto.CustomerResponse{}, apperror.ErrCustomerNotFound
+	}
+	return dto.NewCustomerResponse(*customer), nil
+}
+
+func (s *CustomerServiceImpl) UpdateCustomer(ctx context.Context, id int64, req dto.UpdateCustomerRequest) (dto.CustomerResponse, error) {
+	customer, err := s.customerRepo.FindByID(ctx, id)
+	if err != nil {
+		return dto.CustomerResponse{}, err
+	}
+	if customer == nil {
+		return dto.CustomerResponse{}, apperror.ErrCustomerNotFound
+	}
+
+	if req.Name != nil {
+		customer.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Email != nil {
+		customer.Email = strings.ToLower(*req.Email)
+	}
+	if req.Phone != nil {
+		customer.Phone = req.Phone
+	}
+	customer.UpdatedAt = time.Now()
+
+	err = s.customerRepo.UpdateCustomer(ctx, *customer)
+	if errors.Is(err, repository.ErrCustomerEmailTaken) {
+		return dto.CustomerResponse{}, apperror.ErrCustomerEmailTaken
+	}
+	if err != nil {
+		log.Printf("error updating customer, err: %+v", err)
+		return dto.CustomerResponse{}, err
+	}
+
+	return dto.NewCustomerResponse(*customer), nil
+}
+
+// ListCustomerWallets returns every open wallet the customer owns or shares, with its
+// balances and the customer's role
+func (s *CustomerServiceImpl) ListCustomerWallets(ctx context.Context, id int64) (dto.CustomerWalletsResponse, error) {
+	customer, err := s.customerRepo.FindByID(ctx, id)
+	if err != nil {
+		return dto.CustomerWalletsResponse{}, err
+	}
+	if customer == nil {
+		return dto.CustomerWalletsResponse{}, apperror.ErrCustomerNotFound
+	}
+
+	wallets, err := s.walletRepo.GetListByCustomerID(ctx, id)
+	if err != nil {
+		log.Printf("error listing customer wallets, err: %+v", err)
+		return dto.CustomerWalletsResponse{}, err
+	}
+
+	members, err := s.memberRepo.GetListByCustomerID(ctx, id)
+	if err != nil {
+		log.Printf("error listing customer memberships, err: %+v", err)
+		return dto.CustomerWalletsResponse{}, err
+	}
+	roles := make(map[int64]string, len(members))
+	for _, member := range members {
+		roles[member.WalletID] = member.Role
+	}
+
+	return dto.NewCustomerWalletsResponse(id, wallets, roles), nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/shopspring/decimal"
+)
+
+// calculateFee prices amount with the schedule, rounded to the currency minor unit
+func calculateFee(schedule model.FeeSchedule, amount decimal.Decimal) decimal.Decimal {
+	fee := decimal.Zero
+	switch schedule.Kind {
+	case constant.FeeKindFlat:
+		fee = schedule.FlatAmount
+	case constant.FeeKindPercentage:
+		fee = amount.Mul(schedule.Percentage)
+	case constant.FeeKindTiered:
+		for _, tier := range schedule.Tiers {
+			if tier.UpTo == nil || amount.LessThanOrEqual(*tier.UpTo) {
+				fee = tier.Flat.Add(amount.Mul(tier.Percentage))
+				break
+			}
+		}
+	}
+
+	if schedule.MinFee != nil && fee.LessThan(*schedule.MinFee) {
+		fee = *schedule.MinFee
+	}
+	if schedule.MaxFee != nil && fee.GreaterThan(*schedule.MaxFee) {
+		fee = *schedule.MaxFee
+	}
+
+	units, ok := constant.CurrencyMinorUnits(schedule.Currency)
+	if !ok {
+		units = 18
+	}
+	fee = fee.Round(units)
+	if fee.IsNegative() {
+		return decimal.Zero
+	}
+	return fee
+}
+
+// feeFor returns the fee of an operation, zero when no schedule is active for it
+func (s *TransactionServiceImpl) feeFor(ctx context.Context, transactionType int16, currency string, amount decimal.Decimal) (decimal.Decimal, error) {
+	schedule, err := s.feeScheduleRepo.FindActive(ctx, transactionType, currency)
+	if err != nil {
+		log.Printf("error finding fee schedule, err: %+v", err)
+		return decimal.Zero, err
+	}
+	if schedule == nil {
+		return decimal.Zero, nil
+	}
+	return calculateFee(*schedule, amount), nil
+}
+
+// feeLegs collects the fee from the wallet into the fees wallet, no legs for a free operation
+func feeLegs(wallet *model.Wallet, feesWallet *model.Wallet, fee decimal.Decimal, remarks string) []ledgerLeg {
+	if !fee.IsPositive() {
+		return nil
+	}
+	return []ledgerLeg{
+		{Wallet: wallet, Counterparty: feesWallet, IsDebit: false, Amount: fee, Remarks: remarks + " - Fee", IsFee: true},
+		{Wallet: feesWallet, Counterparty: wallet, IsDebit: true, Amount: fee, Remarks: remarks + " - Fee", IsFee: true},
+	}
+}
+
+// PreviewFee returns what the operation would cost the wallet, without reserving anything
+func (s *TransactionServiceImpl) PreviewFee(ctx context.Context, walletID int64, req dto.FeePreviewRequest) (dto.FeePreviewResponse, error) {
+	currency, err := s.walletCurrency(ctx, walletID)
+	if err != nil {
+		return dto.FeePreviewResponse{}, err
+	}
+	if err := checkPrecision(req.Amount, currency); err != nil {
+		return dto.FeePreviewResponse{}, err
+	}
+
+	fee, err := s.feeFor(ctx, req.TransactionType, currency, req.Amount)
+	if err != nil {
+		return dto.FeePreviewResponse{}, err
+	}
+
+	return dto.FeePreviewResponse{
+		TransactionType: req.TransactionType,
+		Currency:        currency,
+		Amount:          req.Amount,
+		Fee:             fee,
+		Total:           req.Amount.Add(fee),
+	}, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestCalculateFee(t *testing.T) {
+	ptr := func(value string) *decimal.Decimal {
+		d := amount(value)
+		return &d
+	}
+	tiers := model.FeeTiers{
+		{UpTo: ptr("100"), Flat: amount("1.00"), Percentage: decimal.Zero},
+		{UpTo: ptr("1000"), Flat: amount("0.50"), Percentage: amount("0.01")},
+		{Flat: decimal.Zero, Percentage: amount("0.005")},
+	}
+
+	tests := []struct {
+		name     string
+		schedule model.FeeSchedule
+		amount   string
+		want     string
+	}{
+		{
+			name:     "flat",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindFlat, Currency: "USD", FlatAmount: amount("2.50")},
+			amount:   "1000.00",
+			want:     "2.50",
+		},
+		{
+			name:     "percentage rounds to cents",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindPercentage, Currency: "USD", Percentage: amount("0.015")},
+			amount:   "33.33",
+			// 0.49995
+			want: "0.50",
+		},
+		{
+			name:     "percentage rounds to a 0-decimal currency",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindPercentage, Currency: "JPY", Percentage: amount("0.015")},
+			amount:   "1234",
+			// 18.51
+			want: "19",
+		},
+		{
+			name:     "percentage rounds to a 3-decimal currency",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindPercentage, Currency: "KWD", Percentage: amount("0.015")},
+			amount:   "10.249",
+			// 0.153735
+			want: "0.154",
+		},
+		{
+			name:     "clamped to the minimum",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindPercentage, Currency: "USD", Percentage: amount("0.01"), MinFee: ptr("1.00"), MaxFee: ptr("20.00")},
+			amount:   "50.00",
+			want:     "1.00",
+		},
+		{
+			name:     "clamped to the maximum",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindPercentage, Currency: "USD", Percentage: amount("0.01"), MinFee: ptr("1.00"), MaxFee: ptr("20.00")},
+			amount:   "5000.00",
+			want:     "20.00",
+		},
+		{
+			name:     "between minimum and maximum",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindPercentage, Currency: "USD", Percentage: amount("0.01"), MinFee: ptr("1.00"), MaxFee: ptr("20.00")},
+			amount:   "500.00",
+			want:     "5.00",
+		},
+		{
+			name:     "first tier",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindTiered, Currency: "USD", Tiers: tiers},
+			amount:   "100.00",
+			want:     "1.00",
+		},
+		{
+			name:     "middle tier",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindTiered, Currency: "USD", Tiers: tiers},
+			amount:   "100.01",
+			// 0.50 + 1.0001
+			want: "1.50",
+		},
+		{
+			name:     "open-ended last tier",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindTiered, Currency: "USD", Tiers: tiers},
+			amount:   "10000.00",
+			want:     "50.00",
+		},
+		{
+			name:     "tier clamped to the maximum",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindTiered, Currency: "USD", Tiers: tiers, MaxFee: ptr("25.00")},
+			amount:   "10000.00",
+			want:     "25.00",
+		},
+		{
+			name:     "no tier covers the amount",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindTiered, Currency: "USD", Tiers: tiers[:1]},
+			amount:   "500.00",
+			want:     "0",
+		},
+		{
+			name:     "negative fee is free",
+			schedule: model.FeeSchedule{Kind: constant.FeeKindFlat, Currency: "USD", FlatAmount: amount("-1.00")},
+			amount:   "10.00",
+			want:     "0",
+		},
+		{
+			name:     "unknown kind is free",
+			schedule: model.FeeSchedule{Kind: "unknown", Currency: "USD"},
+			amount:   "10.00",
+			want:     "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateFee(tt.schedule, amount(tt.amount))
+			if !got.Equal(amount(tt.want)) {
+				t.Errorf("fee = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
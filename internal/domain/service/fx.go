@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// CreateFXQuote prices a conversion from the wallet to the receiver and locks the rate for
+// the configured quote TTL. Nothing moves until a transfer uses the quote.
+func (s *TransactionServiceImpl) CreateFXQuote(ctx context.Context, walletID int64, req dto.FXQuoteRequest) (dto.FXQuoteResponse, error) {
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		return dto.FXQuoteResponse{}, apperror.ErrInvalidAmount
+	}
+
+	sender, err := s.walletRepo.FindByID(ctx, walletID)
+	if err != nil {
+		return dto.FXQuoteResponse{}, err
+	}
+	if sender == nil {
+		return dto.FXQuoteResponse{}, apperror.ErrWalletNotFound
+	}
+
+	receiver, err := s.walletRepo.FindByID(ctx, req.ReceiverWalletID)
+	if err != nil {
+		return dto.FXQuoteResponse{}, err
+	}
+	if receiver == nil {
+		return dto.FXQuoteResponse{}, apperror.ErrWalletNotFound.WithMessage("receiver wallet not found")
+	}
+
+	if sender.Currency == receiver.Currency {
+		return dto.FXQuoteResponse{}, apperror.ErrInvalidRequest.WithMessage("wallets hold the same currency, transfer without a quote")
+	}
+	if err := checkPrecision(req.Amount, sender.Currency); err != nil {
+		return dto.FXQuoteResponse{}, err
+	}
+
+	midRate, err := s.exchangeRate(ctx, sender.Currency, receiver.Currency)
+	if err != nil {
+		return dto.FXQuoteResponse{}, err
+	}
+
+	// The spread is taken off the rate, the receiver gets the amount rounded down
+	rate := midRate.Mul(decimal.NewFromInt(1).Sub(s.fxSpread))
+	units, _ := constant.CurrencyMinorUnits(receiver.Currency)
+	targetAmount := req.Amount.Mul(rate).Truncate(units)
+	if targetAmount.LessThanOrEqual(decimal.Zero) {
+		return dto.FXQuoteResponse{}, apperror.ErrInvalidAmount.WithMessage("amount is too small to convert")
+	}
+
+	now := time.Now()
+	quote := model.FXQuote{
+		WalletID:         sender.ID,
+		ReceiverWalletID: receiver.ID,
+		SourceCurrency:   sender.Currency,
+		TargetCurrency:   receiver.Currency,
+		SourceAmount:     req.Amount,
+		TargetAmount:     targetAmount,
+		MidRate:          midRate,
+		Spread:           s.fxSpread,
+		Rate:             rate,
+		ExpiresAt:        now.Add(s.fxQuoteTTL),
+		CreatedAt:        now,
+	}
+	if err := s.fxQuoteRepo.CreateFXQuote(ctx, &quote); err != nil {
+		log.Printf("error creating fx quote, err: %+v", err)
+		return dto.FXQuoteResponse{}, err
+	}
+
+	return dto.NewFXQuoteResponse(quote), nil
+}
+
+// exchangeRate returns the mid rate from base to quote, derived from the inverse pair when
+// the provider only has that one
+func (s *TransactionServiceImpl) exchangeRate(ctx context.Context, base string, quote string) (decimal.Decimal, error) {
+	rate, err := s.rateProvider.GetRate(ctx, base, quote)
+	if err != nil {
+		log.Printf("error getting exchange rate %s/%s, err: %+v", base, quote, err)
+		return decimal.Zero, err
+	}
+	if rate != nil && rate.Rate.IsPositive() {
+		return rate.Rate, nil
+	}
+
+	inverse, err := s.rateProvider.GetRate(ctx, quote, base)
+	if err != nil {
+		log.Printf("error getting exchange rate %s/%s, err: %+v", quote, base, err)
+		return decimal.Zero, err
+	}
+	if inverse != nil && inverse.Rate.IsPositive() {
+		return decimal.NewFromInt(1).DivRound(inverse.Rate, 18), nil
+	}
+
+	return decimal.Zero, apperror.ErrRateUnavailable
+}
+
+// transferWithQuote converts through the FX position wallets of both currencies, so each
+// currency balances on its own: sender to FX in the source currency, FX to receiver in the
+// target currency
+func (s *TransactionServiceImpl) transferWithQuote(ctx context.Context, tx *gorm.DB, walletID int64, req dto.TransferRequest) (dto.TransactionResponse, error) {
+	sourceCurrency, err := s.walletCurrency(ctx, walletID)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+	targetCurrency, err := s.walletCurrency(ctx, req.ReceiverWalletID)
+	if errors.Is(err, apperror.ErrWalletNotFound) {
+		return dto.TransactionResponse{}, apperror.ErrWalletNotFound.WithMessage("receiver wallet not found")
+	}
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	feesID, err := s.systemWalletID(ctx, constant.SystemWalletFees, sourceCurrency)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	sourceFXID, err := s.systemWalletID(ctx, constant.SystemWalletFX, sourceCurrency)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+	targetFXID, err := s.systemWalletID(ctx, constant.SystemWalletFX, targetCurrency)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	wallets, err := s.lockWallets(ctx, tx, walletID, req.ReceiverWalletID, sourceFXID, targetFXID, feesID)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	wallet := customerWallet(wallets, walletID)
+	if wallet == nil {
+		return dto.TransactionResponse{}, apperror.ErrWalletNotFound
+	}
+
+	receiverWallet := customerWallet(wallets, req.ReceiverWalletID)
+	if receiverWallet == nil {
+		return dto.TransactionResponse{}, apperror.ErrWalletNotFound.WithMessage("receiver wallet not found")
+	}
+
+	// Wallets first, then the quote, so a quote is only used once
+	quote, err := s.fxQuoteRepo.FindByIDForUpdate(ctx, tx, *req.QuoteID)
+	if err != nil {
+		log.Printf("error fx quote find by id for update, err: %+v", err)
+		return dto.TransactionResponse{}, err
+	}
+	if quote == nil || quote.WalletID != walletID {
+		return dto.TransactionResponse{}, apperror.ErrQuoteNotFound
+	}
+	if quote.ReceiverWalletID != receiverWallet.ID {
+		return dto.TransactionResponse{}, apperror.ErrInvalidRequest.WithMessage("quote was issued for another receiver wallet")
+	}
+	if quote.JournalEntryID != nil {
+		return dto.TransactionResponse{}, apperror.ErrQuoteExpired.WithMessage("quote was already used")
+	}
+	if !quote.ExpiresAt.After(time.Now()) {
+		return dto.TransactionResponse{}, apperror.ErrQuoteExpired
+	}
+	if !req.Amount.IsZero() && !req.Amount.Equal(quote.SourceAmount) {
+		return dto.TransactionResponse{}, apperror.ErrInvalidAmount.WithMessage("amount does not match the quote")
+	}
+
+	// The fee is priced on the source amount, in the source currency
+	fee, err := s.feeFor(ctx, constant.TransactionTypeTransfer, sourceCurrency, quote.SourceAmount)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	if quote.SourceAmount.Add(fee).GreaterThan(wallet.AvailableBalance()) {
+		log.Printf("attempting to transfer more than available balance, wallet: %d", walletID)
+		return dto.TransactionResponse{}, apperror.ErrInsufficientFunds
+	}
+
+	// Limits count the source amount, in the sender's currency
+	if err := s.checkLimits(ctx, tx, wallet, constant.TransactionTypeTransfer, quote.SourceAmount); err != nil {
+		return dto.TransactionResponse{}, err
+	}
+	if err := s.checkSpendLimit(ctx, tx, wallet, quote.SourceAmount, nil); err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	sourceFX := wallets[sourceFXID]
+	targetFX := wallets[targetFXID]
+	legs := []ledgerLeg{
+		{Wallet: wallet, Counterparty: receiverWallet, IsDebit: false, Amount: quote.SourceAmount, Remarks: "Transfer - Send"},
+		{Wallet: sourceFX, Counterparty: wallet, IsDebit: true, Amount: quote.SourceAmount, Remarks: "Transfer - Conversion"},
+		{Wallet: targetFX, Counterparty: receiverWallet, IsDebit: false, Amount: quote.TargetAmount, Remarks: "Transfer - Conversion"},
+		{Wallet: receiverWallet, Counterparty: wallet, IsDebit: true, Amount: quote.TargetAmount, Remarks: "Transfer - Receive"},
+	}
+	legs = append(legs, feeLegs(wallet, wallets[feesID], fee, "Transfer")...)
+
+	transferID, transactionIDs, err := s.postJournalEntry(ctx, tx, model.JournalEntry{
+		Type:           constant.TransactionTypeTransfer,
+		Amount:         quote.SourceAmount,
+		FeeAmount:      fee,
+		Remarks:        "Transfer",
+		FXQuoteID:      &quote.ID,
+		FXRate:         &quote.Rate,
+		FXSpread:       &quote.Spread,
+		SourceCurrency: &quote.SourceCurrency,
+		SourceAmount:   &quote.SourceAmount,
+		TargetCurrency: &quote.TargetCurrency,
+		TargetAmount:   &quote.TargetAmount,
+	}, legs)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	if err := s.fxQuoteRepo.UpdateJournalEntryID(ctx, tx, quote.ID, transferID); err != nil {
+		log.Printf("updating fx quote, err: %+v", err)
+		return dto.TransactionResponse{}, err
+	}
+
+	return dto.TransactionResponse{
+		TransactionID: transactionIDs[0],
+		TransferID:    transferID,
+		Fee:           dto.FeeAmount(fee),
+	}, nil
+}
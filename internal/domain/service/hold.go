@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// holdExpiryBatchSize bounds how many holds one ExpireHolds run releases
+const holdExpiryBatchSize = 100
+
+func (s *TransactionServiceImpl) CreateHold(ctx context.Context, idempotencyKey string, walletID int64, req dto.CreateHoldRequest) (resp dto.HoldResponse, statusCode int, err error) {
+	return withIdempotency(ctx, s.db, s.idempotencyStore, walletID, idempotencyOperationHold, idempotencyKey, req, respondWith[dto.HoldResponse](http.StatusCreated), func(tx *gorm.DB) (dto.HoldResponse, error) {
+		return s.createHold(ctx, tx, walletID, req)
+	})
+}
+
+// createHold reserves the amount out of the available balance. Nothing is posted to the
+// ledger until the hold is captured.
+func (s *TransactionServiceImpl) createHold(ctx context.Context, tx *gorm.DB, walletID int64, req dto.CreateHoldRequest) (dto.HoldResponse, error) {
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		return dto.HoldResponse{}, apperror.ErrInvalidAmount
+	}
+
+	wallets, err := s.lockWallets(ctx, tx, walletID)
+	if err != nil {
+		return dto.HoldResponse{}, err
+	}
+
+	wallet := customerWallet(wallets, walletID)
+	if wallet == nil {
+		return dto.HoldResponse{}, apperror.ErrWalletNotFound
+	}
+
+	if err := checkPrecision(req.Amount, wallet.Currency); err != nil {
+		return dto.HoldResponse{}, err
+	}
+
+	if req.Amount.GreaterThan(wallet.AvailableBalance()) {
+		log.Printf("attempting to hold more than available balance, wallet: %d", walletID)
+		return dto.HoldResponse{}, apperror.ErrInsufficientFunds
+	}
+
+	// Checked again on capture, this only turns away a hold that could never be captured
+	if err := s.checkLimits(ctx, tx, wallet, constant.TransactionTypeWithdraw, req.Amount); err != nil {
+		return dto.HoldResponse{}, err
+	}
+	// An open hold ties up the balance like a spend, so it counts against a spender's limit
+	if err := s.checkSpendLimit(ctx, tx, wallet, req.Amount, nil); err != nil {
+		return dto.HoldResponse{}, err
+	}
+
+	ttl := s.holdDefaultTTL
+	if req.ExpiresInSeconds > 0 {
+		ttl = time.Duration(req.ExpiresInSeconds) * time.Second
+	}
+
+	initiatorID, initiatorCustomerID := initiatorFromContext(ctx)
+	now := time.Now()
+	hold := model.Hold{
+		WalletID:            walletID,
+		InitiatorID:         initiatorID,
+		InitiatorCustomerID: initiatorCustomerID,
+		Amount:              req.Amount,
+		CapturedAmount:      decimal.Zero,
+		Status:              constant.HoldStatusActive,
+		Remarks:             req.Remarks,
+		ExpiresAt:           now.Add(ttl),
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	err = s.holdRepo.CreateHold(ctx, tx, &hold)
+	if err != nil {
+		log.Printf("creating hold, err: %+v", err)
+		return dto.HoldResponse{}, err
+	}
+
+	err = s.walletRepo.UpdateHeldBalance(ctx, tx, walletID, wallet.HeldBalance.Add(req.Amount))
+	if err != nil {
+		log.Printf("updating held balance, err: %+v", err)
+		return dto.HoldResponse{}, err
+	}
+
+	return dto.NewHoldResponse(hold), nil
+}
+
+// GetHold returns a hold only to the wallet it was placed on
+func (s *TransactionServiceImpl) GetHold(ctx context.Context, walletID int64, holdID int64) (dto.HoldResponse, error) {
+	hold, err := s.holdRepo.FindByID(ctx, holdID)
+	if err != nil {
+		return dto.HoldResponse{}, err
+	}
+	if hold == nil || hold.WalletID != walletID {
+		return dto.HoldResponse{}, apperror.ErrHoldNotFound
+	}
+	return dto.NewHoldResponse(*hold), nil
+}
+
+func (s *TransactionServiceImpl) CaptureHold(ctx context.Context, idempotencyKey string, walletID int64, holdID int64, req dto.CaptureHoldRequest) (resp dto.CaptureHoldResponse, statusCode int, err error) {
+	// Scoped to the hold, so the same key can capture different holds of the wallet
+	return withIdempotency(ctx, s.db, s.idempotencyStore, holdID, idempotencyOperationCapture, idempotencyKey, req, respondWith[dto.CaptureHoldResponse](http.StatusOK), func(tx *gorm.DB) (dto.CaptureHoldResponse, error) {
+		return s.captureHold(ctx, tx, walletID, holdID, req)
+	})
+}
+
+// captureHold posts the captured amount as a withdrawal to the cash-out account and
+// releases the whole hold, so anything not captured becomes available again
+func (s *TransactionServiceImpl) captureHold(ctx context.Context, tx *gorm.DB, walletID int64, holdID int64, req dto.CaptureHoldRequest) (dto.CaptureHoldResponse, error) {
+	if req.Amount != nil && req.Amount.LessThanOrEqual(decimal.Zero) {
+		return dto.CaptureHoldResponse{}, apperror.ErrInvalidAmount
+	}
+
+	currency, err := s.walletCurrency(ctx, walletID)
+	if err != nil {
+		return dto.CaptureHoldResponse{}, err
+	}
+	if req.Amount != nil {
+		if err := checkPrecision(*req.Amount, currency); err != nil {
+			return dto.CaptureHoldResponse{}, err
+		}
+	}
+
+	cashOutID, err := s.systemWalletID(ctx, constant.SystemWalletCashOut, currency)
+	if err != nil {
+		return dto.CaptureHoldResponse{}, err
+	}
+
+	wallets, err := s.lockWallets(ctx, tx, walletID, cashOutID)
+	if err != nil {
+		return dto.CaptureHoldResponse{}, err
+	}
+
+	wallet := customerWallet(wallets, walletID)
+	if wallet == nil {
+		return dto.CaptureHoldResponse{}, apperror.ErrWalletNotFound
+	}
+
+	hold, err := s.lockActiveHold(ctx, tx, walletID, holdID)
+	if err != nil {
+		return dto.CaptureHoldResponse{}, err
+	}
+
+	amount := hold.Amount
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount.GreaterThan(hold.Amount) {
+		return dto.CaptureHoldResponse{}, apperror.ErrInvalidAmount.WithMessage("capture amount exceeds the held amount")
+	}
+
+	// A capture is money leaving the wallet, the withdraw limits apply to it
+	if err := s.checkLimits(ctx, tx, wallet, constant.TransactionTypeWithdraw, amount); err != nil {
+		return dto.CaptureHoldResponse{}, err
+	}
+	if err := s.checkSpendLimit(ctx, tx, wallet, amount, hold); err != nil {
+		return dto.CaptureHoldResponse{}, err
+	}
+
+	err = s.walletRepo.UpdateHeldBalance(ctx, tx, walletID, wallet.HeldBalance.Sub(hold.Amount))
+	if err != nil {
+		log.Printf("updating held balance, err: %+v", err)
+		return dto.CaptureHoldResponse{}, err
+	}
+
+	entryID, transactionIDs, err := s.postJournalEntry(ctx, tx, model.JournalEntry{
+		Type:    constant.TransactionTypeHoldCapture,
+		Amount:  amount,
+		Remarks: "Hold Capture",
+	}, []ledgerLeg{
+		{Wallet: wallet, Counterparty: wallets[cashOutID], IsDebit: false, Amount: amount, Remarks: "Hold Capture"},
+		{Wallet: wallets[cashOutID], Counterparty: wallet, IsDebit: true, Amount: amount, Remarks: "Hold Capture"},
+	})
+	if err != nil {
+		return dto.CaptureHoldResponse{}, err
+	}
+
+	hold.Status = constant.HoldStatusCaptured
+	hold.CapturedAmount = amount
+	hold.CaptureJournalEntryID = &entryID
+	hold.UpdatedAt = time.Now()
+	err = s.holdRepo.UpdateHold(ctx, tx, *hold)
+	if err != nil {
+		log.Printf("updating hold, err: %+v", err)
+		return dto.CaptureHoldResponse{}, err
+	}
+
+	return dto.CaptureHoldResponse{
+		TransactionID: transactionIDs[0],
+		Hold:          dto.NewHoldResponse(*hold),
+	}, nil
+}
+
+func (s *TransactionServiceImpl) VoidHold(ctx context.Context, idempotencyKey string, walletID int64, holdID int64) (resp dto.HoldResponse, statusCode int, err error) {
+	return withIdempotency(ctx, s.db, s.idempotencyStore, holdID, idempotencyOperationVoid, idempotencyKey, holdID, respondWith[dto.HoldResponse](http.StatusOK), func(tx *gorm.DB) (dto.HoldResponse, error) {
+		wallets, err := s.lockWallets(ctx, tx, walletID)
+		if err != nil {
+			return dto.HoldResponse{}, err
+		}
+
+		wallet := customerWallet(wallets, walletID)
+		if wallet == nil {
+			return dto.HoldResponse{}, apperror.ErrWalletNotFound
+		}
+
+		hold, err := s.lockActiveHold(ctx, tx, walletID, holdID)
+		if err != nil {
+			return dto.HoldResponse{}, err
+		}
+
+		return s.releaseHold(ctx, tx, wallet, hold, constant.HoldStatusVoided)
+	})
+}
+
+// ExpireHolds releases active holds past their expiry, one DB transaction per hold so a
+// failure only leaves that hold for the next run. It returns how many were released.
+func (s *TransactionServiceImpl) ExpireHolds(ctx context.Context) (int, error) {
+	holds, err := s.holdRepo.GetListExpiredHold(ctx, time.Now(), holdExpiryBatchSize)
+	if err != nil {
+		log.Printf("error listing expired holds, err: %+v", err)
+		return 0, err
+	}
+
+	expired := 0
+	for _, candidate := range holds {
+		released := false
+		err := runInTransaction(ctx, s.db, func(tx *gorm.DB) error {
+			released = false
+
+			// Wallet first, then hold, the same order capture and void lock in
+			wallet, err := s.walletRepo.FindByIDForUpdate(ctx, tx, candidate.WalletID)
+			if err != nil {
+				return err
+			}
+
+			hold, err := s.holdRepo.FindByIDForUpdate(ctx, tx, candidate.ID)
+			if err != nil {
+				return err
+			}
+			if hold == nil || hold.Status != constant.HoldStatusActive || hold.ExpiresAt.After(time.Now()) {
+				return nil
+			}
+
+			if wallet == nil {
+				// The wallet can't be closed with money held, just retire the hold
+				hold.Status = constant.HoldStatusExpired
+				hold.UpdatedAt = time.Now()
+				return s.holdRepo.UpdateHold(ctx, tx, *hold)
+			}
+
+			_, err = s.releaseHold(ctx, tx, wallet, hold, constant.HoldStatusExpired)
+			released = err == nil
+			return err
+		})
+		if err != nil {
+			log.Printf("error expiring hold, hold: %d, err: %+v", candidate.ID, err)
+			continue
+		}
+		if released {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// lockActiveHold locks a hold of the wallet, it must still be active and not past its expiry
+func (s *TransactionServiceImpl) lockActiveHold(ctx context.Context, tx *gorm.DB, walletID int64, holdID int64) (*model.Hold, error) {
+	hold, err := s.holdRepo.FindByIDForUpdate(ctx, tx, holdID)
+	if err != nil {
+		log.Printf("error hold find by id for update, err: %+v", err)
+		return nil, err
+	}
+	if hold == nil || hold.WalletID != walletID {
+		return nil, apperror.ErrHoldNotFound
+	}
+	if hold.Status != constant.HoldStatusActive {
+		return nil, apperror.ErrHoldNotActive
+	}
+	if !hold.ExpiresAt.After(time.Now()) {
+		return nil, apperror.ErrHoldNotActive.WithMessage("hold has expired")
+	}
+	return hold, nil
+}
+
+// releaseHold gives the held amount back to the available balance without touching the ledger
+func (s *TransactionServiceImpl) releaseHold(ctx context.Context, tx *gorm.DB, wallet *model.Wallet, hold *model.Hold, status string) (dto.HoldResponse, error) {
+	err := s.walletRepo.UpdateHeldBalance(ctx, tx, wallet.ID, wallet.HeldBalance.Sub(hold.Amount))
+	if err != nil {
+		log.Printf("updating held balance, err: %+v", err)
+		return dto.HoldResponse{}, err
+	}
+
+	hold.Status = status
+	hold.UpdatedAt = time.Now()
+	err = s.holdRepo.UpdateHold(ctx, tx, *hold)
+	if err != nil {
+		log.Printf("updating hold, err: %+v", err)
+		return dto.HoldResponse{}, err
+	}
+
+	return dto.NewHoldResponse(*hold), nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	idempotencyOperationWithdraw = "withdraw"
+	idempotencyOperationDeposit  = "deposit"
+	idempotencyOperationTransfer = "transfer"
+	idempotencyOperationReverse  = "reverse"
+	idempotencyOperationHold     = "hold"
+	idempotencyOperationCapture  = "capture"
+	idempotencyOperationVoid     = "void"
+	idempotencyOperationAdjust   = "adjustment"
+)
+
+// idempotencyStorageKey namespaces the client key so the same value used by another
+// wallet or another endpoint never collides
+func idempotencyStorageKey(scopeID int64, operation string, idempotencyKey string) string {
+	return fmt.Sprintf("idempotency:%d:%s:%s", scopeID, operation, idempotencyKey)
+}
+
+// requestFingerprint hashes the request body, a reused key must come with the same payload
+func requestFingerprint(req interface{}) (string, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// idempotencyPurgeBatchSize bounds how many expired keys one PurgeIdempotencyKeys run deletes
+const idempotencyPurgeBatchSize = 1000
+
+// statusOf gives the HTTP status of a response, it is stored with the response so a
+// replay answers with the same status as the first request
+type statusOf[T any] func(resp T) int
+
+func respondWith[T any](statusCode int) statusOf[T] {
+	return func(T) int { return statusCode }
+}
+
+// respondAccepted answers 202 for a request that was queued for approval instead of applied
+func respondAccepted[T any](queued func(resp T) bool) statusOf[T] {
+	return func(resp T) int {
+		if queued(resp) {
+			return http.StatusAccepted
+		}
+		return http.StatusOK
+	}
+}
+
+// withIdempotency runs fn in a DB transaction at most once per key within scopeID, usually
+// the wallet. A completed key replays the stored status code and response, a failed run
+// releases the key so the client can retry. The record is saved in the transaction and
+// only completed in the store once it committed.
+func withIdempotency[T any](ctx context.Context, db *gorm.DB, store repository.IdempotencyStore, scopeID int64, operation string, idempotencyKey string, req interface{}, status statusOf[T], fn func(tx *gorm.DB) (T, error)) (T, int, error) {
+	var resp T
+	fingerprint, err := requestFingerprint(req)
+	if err != nil {
+		return resp, 0, err
+	}
+
+	key := idempotencyStorageKey(scopeID, operation, idempotencyKey)
+	record, err := store.Begin(ctx, key, fingerprint)
+	if err != nil {
+		log.Printf("error looking up idempotency key, err: %+v", err)
+		return resp, 0, err
+	}
+
+	if record != nil {
+		return replayIdempotentResponse[T](*record, fingerprint)
+	}
+
+	var completed model.IdempotencyRecord
+	err = runInTransaction(ctx, db, func(tx *gorm.DB) error {
+		// Claimed before any work, a duplicate waits here and replays what the first committed
+		record, err = store.Claim(ctx, tx, key, fingerprint)
+		if err != nil {
+			log.Printf("error claiming idempotency key, err: %+v", err)
+			return err
+		}
+		if record != nil {
+			return nil
+		}
+
+		resp, err = fn(tx)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(resp)
+		if err != nil {
+			return err
+		}
+
+		completed = model.IdempotencyRecord{
+			Key:         key,
+			Status:      model.IdempotencyStatusCompleted,
+			Fingerprint: fingerprint,
+			StatusCode:  status(resp),
+			Body:        body,
+		}
+		return store.Save(ctx, tx, completed)
+	})
+	if err != nil {
+		var empty T
+		if releaseErr := store.Release(ctx, key); releaseErr != nil {
+			log.Printf("error releasing idempotency key, key: %s, err: %+v", key, releaseErr)
+		}
+		return empty, 0, err
+	}
+	if record != nil {
+		// Committed by a concurrent duplicate, cache it like this request would have
+		if err := store.Complete(ctx, *record); err != nil {
+			log.Printf("error completing idempotency key, key: %s, err: %+v", key, err)
+		}
+		return replayIdempotentResponse[T](*record, fingerprint)
+	}
+
+	// The request is applied, a failure here only costs the next duplicate a lookup
+	if err := store.Complete(ctx, completed); err != nil {
+		log.Printf("error completing idempotency key, key: %s, err: %+v", key, err)
+	}
+
+	return resp, completed.StatusCode, nil
+}
+
+// PurgeIdempotencyKeys deletes the expired idempotency keys, it returns how many were deleted
+func (s *TransactionServiceImpl) PurgeIdempotencyKeys(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		deleted, err := s.idempotencyStore.PurgeExpired(ctx, time.Now(), idempotencyPurgeBatchSize)
+		if err != nil {
+			log.Printf("error purging expired idempotency keys, err: %+v", err)
+			return purged, err
+		}
+		purged += int(deleted)
+		if deleted < idempotencyPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+func replayIdempotentResponse[T any](record model.IdempotencyRecord, fingerprint string) (T, int, error) {
+	var resp T
+	if record.Fingerprint != fingerprint {
+		return resp, 0, apperror.ErrIdempotencyKeyMismatch
+	}
+
+	if record.Status != model.IdempotencyStatusCompleted {
+		return resp, 0, apperror.ErrDuplicateRequest
+	}
+
+	if err := json.Unmarshal(record.Body, &resp); err != nil {
+		log.Printf("error decoding stored idempotent response, err: %+v", err)
+		return resp, 0, err
+	}
+	return resp, record.StatusCode, nil
+}
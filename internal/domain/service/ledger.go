@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// initiatorFromContext returns the principal and the customer it acts for found in ctx,
+// both nil for jobs and the insecure header mode
+func initiatorFromContext(ctx context.Context) (*int64, *int64) {
+	principal, ok := principalFromContext(ctx)
+	if !ok || principal.Unrestricted {
+		return nil, nil
+	}
+	return &principal.ID, principal.CustomerID
+}
+
+// ledgerLeg is one posting of a journal entry. Wallet must be locked by the caller unless
+// it is a system wallet.
+// IsDebit true increases the wallet balance, following the transaction_table convention.
+// Counterparty is the wallet on the other side of the movement, when there is one.
+// IsFee marks the legs collecting a fee, they are left alone by reversals.
+type ledgerLeg struct {
+	Wallet       *model.Wallet
+	Counterparty *model.Wallet
+	IsDebit      bool
+	Amount       decimal.Decimal
+	Remarks      string
+	IsFee        bool
+}
+
+func (l ledgerLeg) signedAmount() decimal.Decimal {
+	if l.IsDebit {
+		return l.Amount
+	}
+	return l.Amount.Neg()
+}
+
+// checkBalanced is the ledger invariant, every entry has at least two positive legs and
+// the legs of each currency sum to zero
+func checkBalanced(legs []ledgerLeg) error {
+	if len(legs) < 2 {
+		return apperror.ErrUnbalancedEntry
+	}
+
+	sums := make(map[string]decimal.Decimal)
+	for _, leg := range legs {
+		if leg.Amount.LessThanOrEqual(decimal.Zero) {
+			return apperror.ErrUnbalancedEntry
+		}
+		sums[leg.Wallet.Currency] = sums[leg.Wallet.Currency].Add(leg.signedAmount())
+	}
+
+	for _, sum := range sums {
+		if !sum.IsZero() {
+			return apperror.ErrUnbalancedEntry
+		}
+	}
+	return nil
+}
+
+// postJournalEntry writes one journal entry with its postings and moves every wallet
+// balance by its leg. entry.Amount is the amount of the operation itself. The postings
+// record the caller found in ctx as their initiator, jobs leave it empty.
+// It returns the entry ID and the transaction IDs in leg order.
+func (s *TransactionServiceImpl) postJournalEntry(ctx context.Context, tx *gorm.DB, entry model.JournalEntry, legs []ledgerLeg) (int64, []int64, error) {
+	if err := checkBalanced(legs); err != nil {
+		log.Printf("rejecting unbalanced journal entry, type: %d, legs: %+v", entry.Type, legs)
+		return 0, nil, err
+	}
+
+	now := time.Now()
+	entry.ReversedAmount = decimal.Zero
+	entry.CreatedAt = now
+	entryID, err := s.journalRepo.CreateJournalEntry(ctx, tx, entry)
+	if err != nil {
+		log.Printf("creating journal entry, err: %+v", err)
+		return 0, nil, err
+	}
+
+	initiatorID, initiatorCustomerID := initiatorFromContext(ctx)
+
+	transactionIDs := make([]int64, 0, len(legs))
+	for _, leg := range legs {
+		transaction := model.Transaction{
+			ID:                  0,
+			JournalEntryID:      entryID,
+			WalletID:            leg.Wallet.ID,
+			Type:                entry.Type,
+			IsDebit:             leg.IsDebit,
+			Value:               leg.Amount,
+			Remarks:             leg.Remarks,
+			IsFee:               leg.IsFee,
+			InitiatorID:         initiatorID,
+			InitiatorCustomerID: initiatorCustomerID,
+			Status:              constant.TransactionStatusCompleted,
+			CompletedAt:         &now,
+			CreatedAt:           now,
+		}
+		if leg.Counterparty != nil {
+			transaction.CounterpartyID = &leg.Counterparty.ID
+		}
+
+		// A system wallet is only appended to, its balance is the sum of its postings
+		if leg.Wallet.IsSystem() {
+			transactionID, err := s.transactionRepo.CreateTransaction(ctx, tx, transaction)
+			if err != nil {
+				log.Printf("creating transaction, err: %+v", err)
+				return 0, nil, err
+			}
+			transactionIDs = append(transactionIDs, transactionID)
+			continue
+		}
+
+		// The balance is derived from the posting, never set by the caller
+		newBalance := leg.Wallet.CurrentBalance.Add(leg.signedAmount())
+		transactionID, err := s.createTransactionWithUpdateBalance(ctx, tx, transaction, newBalance)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		leg.Wallet.CurrentBalance = newBalance
+		transactionIDs = append(transactionIDs, transactionID)
+	}
+
+	return entryID, transactionIDs, nil
+}
+
+// transitionStatus moves every posting of an entry to the status, together so the legs
+// never disagree. The caller must hold the journal entry lock.
+func (s *TransactionServiceImpl) transitionStatus(ctx context.Context, tx *gorm.DB, legs []model.Transaction, status string) error {
+	now := time.Now()
+	for _, leg := range legs {
+		if !constant.CanTransitionTransactionStatus(leg.Status, status) {
+			log.Printf("rejecting status transition, transaction: %d, from: %s, to: %s", leg.ID, leg.Status, status)
+			return apperror.ErrInvalidStatusChange
+		}
+
+		err := s.transactionRepo.UpdateStatus(ctx, tx, leg.ID, leg.Status, status, now)
+		if errors.Is(err, repository.ErrTransactionStatusChanged) {
+			return apperror.ErrInvalidStatusChange
+		}
+		if err != nil {
+			log.Printf("updating transaction status, err: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// systemWalletID resolves a system wallet such as CASH_IN in the currency, creating it the
+// first time the currency is used. It still has to be locked before posting.
+func (s *TransactionServiceImpl) systemWalletID(ctx context.Context, code string, currency string) (int64, error) {
+	wallet, err := s.walletRepo.FindSystemWallet(ctx, code, currency)
+	if err != nil {
+		log.Printf("error finding system wallet %s %s, err: %+v", code, currency, err)
+		return 0, err
+	}
+	if wallet != nil {
+		return wallet.ID, nil
+	}
+
+	if err := s.walletRepo.CreateSystemWallet(ctx, code, currency); err != nil {
+		log.Printf("error creating system wallet %s %s, err: %+v", code, currency, err)
+		return 0, err
+	}
+
+	wallet, err = s.walletRepo.FindSystemWallet(ctx, code, currency)
+	if err != nil {
+		log.Printf("error finding system wallet %s %s, err: %+v", code, currency, err)
+		return 0, err
+	}
+	if wallet == nil {
+		return 0, fmt.Errorf("system wallet %s %s is missing", code, currency)
+	}
+	return wallet.ID, nil
+}
+
+// customerWallet picks a locked wallet a customer may operate on, system wallets don't qualify
+func customerWallet(wallets map[int64]*model.Wallet, walletID int64) *model.Wallet {
+	wallet := wallets[walletID]
+	if wallet == nil || wallet.IsSystem() {
+		return nil
+	}
+	return wallet
+}
+
+// balanceDriftReportLimit bounds how many drifting wallets one ReconcileBalances run reports
+const balanceDriftReportLimit = 100
+
+// ReconcileBalances checks the cached balance of every customer wallet against the sum of
+// its postings and logs each wallet where they differ. Nothing is corrected, a drift is a
+// bug to look into. It returns how many wallets drifted.
+func (s *TransactionServiceImpl) ReconcileBalances(ctx context.Context) (int, error) {
+	drifts, err := s.walletRepo.GetListBalanceDrift(ctx, balanceDriftReportLimit)
+	if err != nil {
+		log.Printf("error listing wallet balance drift, err: %+v", err)
+		return 0, err
+	}
+
+	for _, drift := range drifts {
+		log.Printf("wallet balance differs from its postings, wallet: %d, balance: %s, postings: %s", drift.WalletID, drift.Balance, drift.PostedBalance)
+	}
+	return len(drifts), nil
+}
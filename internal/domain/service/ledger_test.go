@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+)
+
+func testWallet(id int64, currency string, balance string) *model.Wallet {
+	return &model.Wallet{ID: id, Currency: currency, CurrentBalance: decimal.RequireFromString(balance)}
+}
+
+func testSystemWallet(id int64, code string, currency string) *model.Wallet {
+	return &model.Wallet{ID: id, Currency: currency, SystemCode: &code}
+}
+
+func amount(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func TestCheckBalanced(t *testing.T) {
+	usdA := testWallet(1, "USD", "0")
+	usdB := testWallet(2, "USD", "0")
+	usdPool := testSystemWallet(3, "FX", "USD")
+	jpyPool := testSystemWallet(4, "FX", "JPY")
+	jpyB := testWallet(5, "JPY", "0")
+
+	tests := []struct {
+		name string
+		legs []ledgerLeg
+		ok   bool
+	}{
+		{
+			name: "transfer",
+			legs: []ledgerLeg{
+				{Wallet: usdA, IsDebit: false, Amount: amount("10.00")},
+				{Wallet: usdB, IsDebit: true, Amount: amount("10.00")},
+			},
+			ok: true,
+		},
+		{
+			name: "conversion balances per currency",
+			legs: []ledgerLeg{
+				{Wallet: usdA, IsDebit: false, Amount: amount("100.00")},
+				{Wallet: usdPool, IsDebit: true, Amount: amount("100.00")},
+				{Wallet: jpyPool, IsDebit: false, Amount: amount("14999")},
+				{Wallet: jpyB, IsDebit: true, Amount: amount("14999")},
+			},
+			ok: true,
+		},
+		{name: "no legs"},
+		{
+			name: "single leg",
+			legs: []ledgerLeg{{Wallet: usdA, IsDebit: true, Amount: amount("10.00")}},
+		},
+		{
+			name: "amounts differ",
+			legs: []ledgerLeg{
+				{Wallet: usdA, IsDebit: false, Amount: amount("10.00")},
+				{Wallet: usdB, IsDebit: true, Amount: amount("10.01")},
+			},
+		},
+		{
+			name: "both legs credit",
+			legs: []ledgerLeg{
+				{Wallet: usdA, IsDebit: true, Amount: amount("10.00")},
+				{Wallet: usdB, IsDebit: true, Amount: amount("10.00")},
+			},
+		},
+		{
+			name: "zero legs",
+			legs: []ledgerLeg{
+				{Wallet: usdA, IsDebit: false, Amount: decimal.Zero},
+				{Wallet: usdB, IsDebit: true, Amount: decimal.Zero},
+			},
+		},
+		{
+			name: "negative legs",
+			legs: []ledgerLeg{
+				{Wallet: usdA, IsDebit: false, Amount: amount("-10.00")},
+				{Wallet: usdB, IsDebit: true, Amount: amount("-10.00")},
+			},
+		},
+		{
+			name: "currencies netted against each other",
+			legs: []ledgerLeg{
+				{Wallet: usdA, IsDebit: false, Amount: amount("100")},
+				{Wallet: jpyB, IsDebit: true, Amount: amount("100")},
+			},
+		},
+		{
+			name: "conversion off by one minor unit",
+			legs: []ledgerLeg{
+				{Wallet: usdA, IsDebit: false, Amount: amount("100.00")},
+				{Wallet: usdPool, IsDebit: true, Amount: amount("100.00")},
+				{Wallet: jpyPool, IsDebit: false, Amount: amount("14999")},
+				{Wallet: jpyB, IsDebit: true, Amount: amount("15000")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBalanced(tt.legs)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, apperror.ErrUnbalancedEntry) {
+				t.Fatalf("err = %v, want ErrUnbalancedEntry", err)
+			}
+		})
+	}
+}
+
+func TestReversalLegs(t *testing.T) {
+	const (
+		senderID   = 1
+		usdPoolID  = 2
+		targetPool = 3
+		receiverID = 4
+		feesID     = 5
+	)
+
+	// conversion is the postings of a 100.00 USD transfer converted to target, with a fee
+	conversion := func(target string) []model.Transaction {
+		receiver := int64(receiverID)
+		sender := int64(senderID)
+		return []model.Transaction{
+			{WalletID: senderID, CounterpartyID: &receiver, IsDebit: false, Value: amount("100.00"), Remarks: "Transfer - Send"},
+			{WalletID: usdPoolID, CounterpartyID: &sender, IsDebit: true, Value: amount("100.00"), Remarks: "Transfer - Conversion"},
+			{WalletID: targetPool, CounterpartyID: &receiver, IsDebit: false, Value: amount(target), Remarks: "Transfer - Conversion"},
+			{WalletID: receiverID, CounterpartyID: &sender, IsDebit: true, Value: amount(target), Remarks: "Transfer - Receive"},
+			{WalletID: senderID, IsDebit: false, Value: amount("1.50"), Remarks: "Transfer - Fee", IsFee: true},
+			{WalletID: feesID, IsDebit: true, Value: amount("1.50"), Remarks: "Transfer - Fee", IsFee: true},
+		}
+	}
+	wallets := func(targetCurrency string, receiverBalance string) map[int64]*model.Wallet {
+		return map[int64]*model.Wallet{
+			senderID:   testWallet(senderID, "USD", "0"),
+			usdPoolID:  testSystemWallet(usdPoolID, "FX", "USD"),
+			targetPool: testSystemWallet(targetPool, "FX", targetCurrency),
+			receiverID: testWallet(receiverID, targetCurrency, receiverBalance),
+			feesID:     testSystemWallet(feesID, "FEES", "USD"),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		legs    []model.Transaction
+		wallets map[int64]*model.Wallet
+		amount  decimal.Decimal
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "full reversal in the same currency",
+			legs:    conversion("100.00"),
+			wallets: wallets("USD", "100.00"),
+			amount:  amount("100.00"),
+			want:    []string{"100.00", "100.00", "100.00", "100.00"},
+		},
+		{
+			name:    "partial reversal rounds in a 0-decimal currency",
+			legs:    conversion("14999"),
+			wallets: wallets("JPY", "14999"),
+			amount:  amount("33.33"),
+			// 14999 * 33.33 / 100 = 4999.1667
+			want: []string{"33.33", "33.33", "4999", "4999"},
+		},
+		{
+			name:    "partial reversal rounds half up in a 0-decimal currency",
+			legs:    conversion("15001"),
+			wallets: wallets("JPY", "15001"),
+			amount:  amount("50.00"),
+			// 15001 * 50 / 100 = 7500.5
+			want: []string{"50.00", "50.00", "7501", "7501"},
+		},
+		{
+			name:    "partial reversal rounds in a 3-decimal currency",
+			legs:    conversion("30.751"),
+			wallets: wallets("KWD", "30.751"),
+			amount:  amount("33.33"),
+			// 30.751 * 33.33 / 100 = 10.2493083
+			want: []string{"33.33", "33.33", "10.249", "10.249"},
+		},
+		{
+			name:    "partial reversal too small for the target currency",
+			legs:    conversion("150"),
+			wallets: wallets("JPY", "150"),
+			amount:  amount("0.01"),
+			wantErr: apperror.ErrInvalidAmount,
+		},
+		{
+			name:    "receiver spent the money",
+			legs:    conversion("14999"),
+			wallets: wallets("JPY", "4998"),
+			amount:  amount("33.33"),
+			wantErr: apperror.ErrInsufficientFunds,
+		},
+		{
+			name:    "closed wallet",
+			legs:    conversion("14999"),
+			wallets: map[int64]*model.Wallet{senderID: testWallet(senderID, "USD", "0")},
+			amount:  amount("33.33"),
+			wantErr: apperror.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reversalLegs(tt.legs, tt.wallets, amount("100.00"), tt.amount)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			// Fees are not refunded, the other legs are inverted in order
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d legs, want %d", len(got), len(tt.want))
+			}
+			for i, leg := range got {
+				if !leg.Amount.Equal(amount(tt.want[i])) {
+					t.Errorf("leg %d amount = %s, want %s", i, leg.Amount, tt.want[i])
+				}
+				if leg.IsDebit == tt.legs[i].IsDebit {
+					t.Errorf("leg %d is not inverted", i)
+				}
+				if leg.Wallet.ID != tt.legs[i].WalletID {
+					t.Errorf("leg %d wallet = %d, want %d", i, leg.Wallet.ID, tt.legs[i].WalletID)
+				}
+			}
+			if err := checkBalanced(got); err != nil {
+				t.Errorf("reversal is unbalanced: %v", err)
+			}
+		})
+	}
+}
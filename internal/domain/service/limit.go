@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// limitUsageTypes lists the transaction types counted against the limit of a type. A hold
+// capture takes money out like a withdrawal, so it is bound by the withdraw limit.
+func limitUsageTypes(transactionType int16) []int16 {
+	if transactionType == constant.TransactionTypeWithdraw {
+		return []int16{constant.TransactionTypeWithdraw, constant.TransactionTypeHoldCapture}
+	}
+	return []int16{transactionType}
+}
+
+// checkLimits rejects an outgoing amount, fee excluded, that would break the wallet limit
+// for the transaction type. The wallet must be locked by the caller, so the usage read
+// here can't move before the posting and two requests can't both take the last headroom.
+func (s *TransactionServiceImpl) checkLimits(ctx context.Context, tx *gorm.DB, wallet *model.Wallet, transactionType int16, amount decimal.Decimal) error {
+	limit, err := s.limitRepo.FindForWallet(ctx, *wallet, transactionType)
+	if err != nil {
+		log.Printf("error finding transaction limit, err: %+v", err)
+		return err
+	}
+	if limit == nil {
+		return nil
+	}
+
+	if limit.PerTransaction != nil && amount.GreaterThan(*limit.PerTransaction) {
+		return limitExceeded("per_transaction", *limit.PerTransaction, decimal.Zero, *limit.PerTransaction, nil)
+	}
+
+	now := time.Now().UTC()
+	periods := []struct {
+		name   string
+		start  time.Time
+		resets time.Time
+		amount *decimal.Decimal
+		count  *int64
+	}{
+		{
+			name:   "daily",
+			start:  time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+			resets: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+			amount: limit.DailyAmount,
+			count:  limit.DailyCount,
+		},
+		{
+			name:   "monthly",
+			start:  time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+			resets: time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC),
+			amount: limit.MonthlyAmount,
+			count:  limit.MonthlyCount,
+		},
+	}
+
+	for _, period := range periods {
+		if period.amount == nil && period.count == nil {
+			continue
+		}
+
+		usage, err := s.transactionRepo.GetOutgoingUsage(ctx, tx, wallet.ID, limitUsageTypes(transactionType), period.start)
+		if err != nil {
+			log.Printf("error getting outgoing usage, err: %+v", err)
+			return err
+		}
+
+		if period.amount != nil && usage.Amount.Add(amount).GreaterThan(*period.amount) {
+			headroom := decimal.Max(period.amount.Sub(usage.Amount), decimal.Zero)
+			return limitExceeded(period.name+"_amount", *period.amount, usage.Amount, headroom, &period.resets)
+		}
+		if period.count != nil && usage.Count >= *period.count {
+			limitValue := decimal.NewFromInt(*period.count)
+			return limitExceeded(period.name+"_count", limitValue, decimal.NewFromInt(usage.Count), decimal.Zero, &period.resets)
+		}
+	}
+	return nil
+}
+
+// checkSpendLimit applies the daily spend limit of a spender on a shared wallet to the
+// caller in ctx. Owners, callers acting for no customer and jobs are not limited. Like
+// checkLimits it needs the wallet locked. capturing is the hold a capture releases, when
+// the spender placed it its amount was counted already and is taken off again.
+func (s *TransactionServiceImpl) checkSpendLimit(ctx context.Context, tx *gorm.DB, wallet *model.Wallet, amount decimal.Decimal, capturing *model.Hold) error {
+	principal, ok := principalFromContext(ctx)
+	if !ok || principal.CustomerID == nil {
+		return nil
+	}
+
+	member, err := s.memberRepo.FindMember(ctx, wallet.ID, *principal.CustomerID)
+	if err != nil {
+		log.Printf("error finding wallet member, err: %+v", err)
+		return err
+	}
+	if member == nil || member.Role != constant.WalletRoleSpender || member.SpendLimit == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	spent, err := s.transactionRepo.GetMemberSpend(ctx, tx, wallet.ID, member.CustomerID, dayStart)
+	if err != nil {
+		log.Printf("error getting member spend, err: %+v", err)
+		return err
+	}
+
+	if capturing != nil && capturing.InitiatorCustomerID != nil && *capturing.InitiatorCustomerID == member.CustomerID {
+		spent = spent.Sub(capturing.Amount)
+	}
+
+	if spent.Add(amount).GreaterThan(*member.SpendLimit) {
+		resetsAt := dayStart.AddDate(0, 0, 1)
+		headroom := decimal.Max(member.SpendLimit.Sub(spent), decimal.Zero)
+		return limitExceeded("member_daily_spend", *member.SpendLimit, spent, headroom, &resetsAt)
+	}
+	return nil
+}
+
+// limitExceeded tells the client which limit was hit and what is left under it, resetsAt
+// is nil for the per-transaction limit since it never resets
+func limitExceeded(name string, limit decimal.Decimal, used decimal.Decimal, headroom decimal.Decimal, resetsAt *time.Time) error {
+	details := map[string]interface{}{
+		"limit":    name,
+		"max":      limit,
+		"used":     used,
+		"headroom": headroom,
+	}
+	if resetsAt != nil {
+		details["resets_at"] = *resetsAt
+	}
+	return apperror.ErrLimitExceeded.WithMessage(name + " limit exceeded").WithDetails(details)
+}
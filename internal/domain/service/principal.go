@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+)
+
+// Principal is the authenticated caller of a request with its role on each wallet it may
+// use, see constant.WalletRoleOwner. Unrestricted is only set in the insecure header mode,
+// where the caller is trusted.
+type Principal struct {
+	ID           int64
+	Name         string
+	IsAdmin      bool
+	CustomerID   *int64
+	WalletRoles  map[int64]string
+	Unrestricted bool
+}
+
+// CanAccessWallet tells whether the principal holds at least the role on the wallet
+func (p Principal) CanAccessWallet(walletID int64, role string) bool {
+	if p.IsAdmin || p.Unrestricted {
+		return true
+	}
+	return constant.WalletRoleAllows(p.WalletRoles[walletID], role)
+}
+
+// CanAccessCustomer tells whether the principal may see and manage the customer
+func (p Principal) CanAccessCustomer(customerID int64) bool {
+	if p.IsAdmin || p.Unrestricted {
+		return true
+	}
+	return p.CustomerID != nil && *p.CustomerID == customerID
+}
+
+type principalContextKey struct{}
+
+// ContextWithPrincipal attaches the caller to the request context, so the ledger can record
+// who initiated a posting and apply the spend limit of a shared wallet member
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// principalFromContext returns the caller, false for background jobs
+func principalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
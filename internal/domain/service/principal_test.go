@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
+)
+
+func TestPrincipalCanAccessWallet(t *testing.T) {
+	member := service.Principal{
+		ID: 1,
+		WalletRoles: map[int64]string{
+			10: constant.WalletRoleOwner,
+			11: constant.WalletRoleSpender,
+			12: constant.WalletRoleViewer,
+		},
+	}
+
+	tests := []struct {
+		name      string
+		principal service.Principal
+		walletID  int64
+		role      string
+		want      bool
+	}{
+		{name: "owner may own", principal: member, walletID: 10, role: constant.WalletRoleOwner, want: true},
+		{name: "owner may spend", principal: member, walletID: 10, role: constant.WalletRoleSpender, want: true},
+		{name: "owner may view", principal: member, walletID: 10, role: constant.WalletRoleViewer, want: true},
+		{name: "spender may not own", principal: member, walletID: 11, role: constant.WalletRoleOwner},
+		{name: "spender may spend", principal: member, walletID: 11, role: constant.WalletRoleSpender, want: true},
+		{name: "spender may view", principal: member, walletID: 11, role: constant.WalletRoleViewer, want: true},
+		{name: "viewer may not spend", principal: member, walletID: 12, role: constant.WalletRoleSpender},
+		{name: "viewer may view", principal: member, walletID: 12, role: constant.WalletRoleViewer, want: true},
+		{name: "other wallet", principal: member, walletID: 13, role: constant.WalletRoleViewer},
+		{name: "unknown role required", principal: member, walletID: 10, role: "admin"},
+		{name: "empty role required", principal: member, walletID: 10, role: ""},
+		{name: "no wallets", principal: service.Principal{ID: 2}, walletID: 10, role: constant.WalletRoleViewer},
+		{name: "admin", principal: service.Principal{ID: 3, IsAdmin: true}, walletID: 13, role: constant.WalletRoleOwner, want: true},
+		{name: "unrestricted", principal: service.Principal{Unrestricted: true}, walletID: 13, role: constant.WalletRoleOwner, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.CanAccessWallet(tt.walletID, tt.role); got != tt.want {
+				t.Errorf("CanAccessWallet(%d, %q) = %v, want %v", tt.walletID, tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrincipalCanAccessCustomer(t *testing.T) {
+	customerID := int64(5)
+
+	tests := []struct {
+		name       string
+		principal  service.Principal
+		customerID int64
+		want       bool
+	}{
+		{name: "own customer", principal: service.Principal{ID: 1, CustomerID: &customerID}, customerID: 5, want: true},
+		{name: "other customer", principal: service.Principal{ID: 1, CustomerID: &customerID}, customerID: 6},
+		{name: "no customer", principal: service.Principal{ID: 1}, customerID: 5},
+		{name: "admin", principal: service.Principal{ID: 2, IsAdmin: true}, customerID: 6, want: true},
+		{name: "unrestricted", principal: service.Principal{Unrestricted: true}, customerID: 6, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.CanAccessCustomer(tt.customerID); got != tt.want {
+				t.Errorf("CanAccessCustomer(%d) = %v, want %v", tt.customerID, got, tt.want)
+			}
+		})
+	}
+}
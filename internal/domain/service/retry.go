@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTransactionAttempts = 5
+	baseRetryBackoff       = 20 * time.Millisecond
+	maxRetryBackoff        = 500 * time.Millisecond
+
+	// Postgres SQLSTATE codes that are safe to retry from the start
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+	}
+	return false
+}
+
+// runInTransaction runs fn in a DB transaction and retries it with bounded
+// exponential backoff when Postgres aborts it because of a deadlock or
+// serialization failure. fn must be safe to run more than once. Once the
+// attempts run out apperror.ErrTransactionConflict is returned.
+func runInTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	backoff := baseRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.WithContext(ctx).Transaction(fn)
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+
+		if attempt == maxTransactionAttempts {
+			log.Printf("giving up transaction after %d attempts, err: %+v", attempt, err)
+			return apperror.ErrTransactionConflict
+		}
+
+		// Full jitter keeps the competing transactions from retrying in lockstep
+		sleep := time.Duration(rand.Int63n(int64(backoff)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+func (s *TransactionServiceImpl) ReverseTransaction(ctx context.Context, idempotencyKey string, transactionID int64, req dto.ReverseRequest) (resp dto.ReversalResponse, statusCode int, err error) {
+	// Reversing an adjustment waits for a second admin like the adjustment did
+	queued := func(resp dto.ReversalResponse) bool { return resp.ApprovalID != nil }
+	return withIdempotency(ctx, s.db, s.idempotencyStore, transactionID, idempotencyOperationReverse, idempotencyKey, req, respondAccepted(queued), func(tx *gorm.DB) (dto.ReversalResponse, error) {
+		return s.reverse(ctx, tx, transactionID, req)
+	})
+}
+
+// reverse posts the reversal right away, except for an adjustment. Reversing one moves a
+// balance by hand as well, so it waits for a second approver like the adjustment did.
+func (s *TransactionServiceImpl) reverse(ctx context.Context, tx *gorm.DB, transactionID int64, req dto.ReverseRequest) (dto.ReversalResponse, error) {
+	original, err := s.reversibleTransaction(ctx, transactionID, req)
+	if err != nil {
+		return dto.ReversalResponse{}, err
+	}
+
+	if original.Type == constant.TransactionTypeAdjustment {
+		return s.queueReversal(ctx, tx, original, req)
+	}
+
+	resp, _, err := s.postReversal(ctx, tx, original, req)
+	return resp, err
+}
+
+// reversibleTransaction finds the transaction to reverse, it must be completed
+func (s *TransactionServiceImpl) reversibleTransaction(ctx context.Context, transactionID int64, req dto.ReverseRequest) (*model.Transaction, error) {
+	if req.Amount != nil && req.Amount.LessThanOrEqual(decimal.Zero) {
+		return nil, apperror.ErrInvalidAmount
+	}
+
+	original, err := s.transactionRepo.FindByID(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return nil, apperror.ErrTransactionNotFound
+	}
+	if original.Status != constant.TransactionStatusCompleted {
+		return nil, apperror.ErrNotReversible.WithMessage("only a completed transaction can be reversed")
+	}
+	return original, nil
+}
+
+// postReversal posts a compensating journal entry for the entry the transaction belongs to.
+// Every leg is inverted and scaled to the reversed amount, so a transfer is undone on
+// both wallets at once. It also returns the ID of the first reversal posting.
+func (s *TransactionServiceImpl) postReversal(ctx context.Context, tx *gorm.DB, original *model.Transaction, req dto.ReverseRequest) (dto.ReversalResponse, int64, error) {
+	transactionID := original.ID
+
+	// Locking the entry keeps two partial reversals from both passing the remaining check
+	entry, err := s.journalRepo.FindByIDForUpdate(ctx, tx, original.JournalEntryID)
+	if err != nil {
+		return dto.ReversalResponse{}, 0, err
+	}
+	if entry == nil {
+		return dto.ReversalResponse{}, 0, apperror.ErrTransactionNotFound
+	}
+	if entry.ReversalOfID != nil {
+		return dto.ReversalResponse{}, 0, apperror.ErrNotReversible
+	}
+
+	remaining := entry.Amount.Sub(entry.ReversedAmount)
+	if remaining.LessThanOrEqual(decimal.Zero) {
+		return dto.ReversalResponse{}, 0, apperror.ErrReversalExceedsAmount.WithMessage("transaction is already fully reversed")
+	}
+
+	amount := remaining
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount.GreaterThan(remaining) {
+		return dto.ReversalResponse{}, 0, apperror.ErrReversalExceedsAmount
+	}
+
+	legs, err := s.transactionRepo.GetListTransactionByJournalEntryID(ctx, entry.ID)
+	if err != nil {
+		return dto.ReversalResponse{}, 0, err
+	}
+
+	walletIDs := make([]int64, 0, len(legs))
+	for _, leg := range legs {
+		walletIDs = append(walletIDs, leg.WalletID)
+	}
+	wallets, err := s.lockWallets(ctx, tx, walletIDs...)
+	if err != nil {
+		return dto.ReversalResponse{}, 0, err
+	}
+
+	// The entry amount of a conversion is in its source currency
+	if wallet := wallets[original.WalletID]; wallet != nil && req.Amount != nil {
+		currency := wallet.Currency
+		if entry.SourceCurrency != nil {
+			currency = *entry.SourceCurrency
+		}
+		if err := checkPrecision(amount, currency); err != nil {
+			return dto.ReversalResponse{}, 0, err
+		}
+	}
+
+	// A deposit still clearing is taken back from its uncleared part first
+	if entry.Type == constant.TransactionTypeDeposit {
+		if err := s.reduceDepositClearing(ctx, tx, wallets, entry.ID, amount); err != nil {
+			return dto.ReversalResponse{}, 0, err
+		}
+	}
+
+	reversalLegs, err := reversalLegs(legs, wallets, entry.Amount, amount)
+	if err != nil {
+		return dto.ReversalResponse{}, 0, err
+	}
+
+	remarks := strings.TrimSpace(req.Reason)
+	if remarks == "" {
+		remarks = "Reversal"
+	}
+
+	reversalID, reversalTransactionIDs, err := s.postJournalEntry(ctx, tx, model.JournalEntry{
+		Type:         constant.TransactionTypeReversal,
+		Amount:       amount,
+		ReversalOfID: &entry.ID,
+		Remarks:      remarks,
+	}, reversalLegs)
+	if err != nil {
+		return dto.ReversalResponse{}, 0, err
+	}
+
+	totalReversed := entry.ReversedAmount.Add(amount)
+	if err := s.journalRepo.UpdateReversedAmount(ctx, tx, entry.ID, totalReversed); err != nil {
+		log.Printf("updating reversed amount, err: %+v", err)
+		return dto.ReversalResponse{}, 0, err
+	}
+
+	// The original only counts as reversed once nothing is left to reverse, its fee stays
+	// completed since it is kept
+	if totalReversed.Equal(entry.Amount) {
+		reversed := make([]model.Transaction, 0, len(legs))
+		for _, leg := range legs {
+			if !leg.IsFee {
+				reversed = append(reversed, leg)
+			}
+		}
+		if err := s.transitionStatus(ctx, tx, reversed, constant.TransactionStatusReversed); err != nil {
+			return dto.ReversalResponse{}, 0, err
+		}
+	}
+
+	return dto.ReversalResponse{
+		ReversalID:     reversalID,
+		TransactionID:  transactionID,
+		JournalEntryID: entry.ID,
+		Amount:         amount,
+		TotalReversed:  totalReversed,
+		Remaining:      entry.Amount.Sub(totalReversed),
+	}, reversalTransactionIDs[0], nil
+}
+
+// reversalLegs inverts the original postings scaled by amount/entryAmount, rounded to the
+// currency of each wallet so the legs of a currency still balance. A conversion is
+// reversed at its original rate and fees are not refunded. Customer wallets must be able
+// to give the money back, system wallets may go negative.
+func reversalLegs(legs []model.Transaction, wallets map[int64]*model.Wallet, entryAmount decimal.Decimal, amount decimal.Decimal) ([]ledgerLeg, error) {
+	outgoing := map[int64]decimal.Decimal{}
+	reversal := make([]ledgerLeg, 0, len(legs))
+	for _, leg := range legs {
+		if leg.IsFee {
+			continue
+		}
+
+		wallet := wallets[leg.WalletID]
+		if wallet == nil {
+			return nil, apperror.ErrWalletNotFound.WithMessage("a wallet of the original transaction is closed")
+		}
+
+		value := amount
+		if !leg.Value.Equal(entryAmount) {
+			units, ok := constant.CurrencyMinorUnits(wallet.Currency)
+			if !ok {
+				units = 18
+			}
+			value = leg.Value.Mul(amount).DivRound(entryAmount, units)
+		}
+		if !value.IsPositive() {
+			return nil, apperror.ErrInvalidAmount.WithMessage("amount is too small to reverse")
+		}
+
+		var counterparty *model.Wallet
+		if leg.CounterpartyID != nil {
+			counterparty = wallets[*leg.CounterpartyID]
+		}
+
+		// The original leg credited this wallet, the reversal takes it back
+		if leg.IsDebit && !wallet.IsSystem() {
+			outgoing[wallet.ID] = outgoing[wallet.ID].Add(value)
+			if outgoing[wallet.ID].GreaterThan(wallet.AvailableBalance()) {
+				return nil, apperror.ErrInsufficientFunds.WithMessage("wallet balance is too low to reverse the transaction")
+			}
+		}
+
+		reversal = append(reversal, ledgerLeg{
+			Wallet:       wallet,
+			Counterparty: counterparty,
+			IsDebit:      !leg.IsDebit,
+			Amount:       value,
+			Remarks:      "Reversal - " + leg.Remarks,
+		})
+	}
+	return reversal, nil
+}
@@ -1,8 +1,8 @@
 package service
 
 import (
+	"github.com/krisnadwipayana07/restful-fintech/configs"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
-	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
@@ -10,12 +10,16 @@ type Service struct {
 	db          *gorm.DB
 	Transaction TransactionService
 	Wallet      WalletService
+	Auth        AuthService
+	Customer    CustomerService
 }
 
-func New(repo repository.Repository, db *gorm.DB, redis *redis.Client) (Service, error) {
+func New(repo repository.Repository, db *gorm.DB, config *configs.Config) (Service, error) {
 	return Service{
 		db:          db,
-		Transaction: NewTransactionService(db, redis, repo.Transaction, repo.Wallet),
-		Wallet:      NewWalletService(repo.Transaction, repo.Wallet),
+		Transaction: NewTransactionService(db, repo.Idempotency, repo, config),
+		Wallet:      NewWalletService(db, repo),
+		Customer:    NewCustomerService(repo.Customer, repo.Wallet, repo.Member),
+		Auth:        NewAuthService(repo.Principal, repo.Member, repo.Nonce, config.AuthJWTSecret, config.SignatureMaxSkew),
 	}, nil
 }
@@ -2,37 +2,100 @@ package service
 
 import (
 	"context"
-	"errors"
 	"log"
+	"net/http"
+	"sort"
 	"time"
 
+	"github.com/krisnadwipayana07/restful-fintech/configs"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
 	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
-	"github.com/redis/go-redis/v9"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
 type TransactionService interface {
-	Withdraw(ctx context.Context, idempotencyKey string, walletID int64, req dto.AmountRequest) (resp dto.TransactionResponse, err error)
-	Deposit(ctx context.Context, idempotencyKey string, walletID int64, req dto.AmountRequest) (resp dto.TransactionResponse, err error)
-	Transfer(ctx context.Context, idempotencyKey string, walletID int64, req dto.TransferRequest) (resp dto.TransactionResponse, err error)
+	Withdraw(ctx context.Context, idempotencyKey string, walletID int64, req dto.AmountRequest) (resp dto.TransactionResponse, statusCode int, err error)
+	Deposit(ctx context.Context, idempotencyKey string, walletID int64, req dto.AmountRequest) (resp dto.TransactionResponse, statusCode int, err error)
+	Transfer(ctx context.Context, idempotencyKey string, walletID int64, req dto.TransferRequest) (resp dto.TransactionResponse, statusCode int, err error)
+	GetTransfer(ctx context.Context, walletID int64, transferID int64) (dto.TransferDetailResponse, error)
+	CreateFXQuote(ctx context.Context, walletID int64, req dto.FXQuoteRequest) (dto.FXQuoteResponse, error)
+	PreviewFee(ctx context.Context, walletID int64, req dto.FeePreviewRequest) (dto.FeePreviewResponse, error)
+	GetTransaction(ctx context.Context, walletID int64, transactionID int64) (dto.TransactionDetailResponse, error)
+	ReverseTransaction(ctx context.Context, idempotencyKey string, transactionID int64, req dto.ReverseRequest) (resp dto.ReversalResponse, statusCode int, err error)
+	CreateHold(ctx context.Context, idempotencyKey string, walletID int64, req dto.CreateHoldRequest) (resp dto.HoldResponse, statusCode int, err error)
+	GetHold(ctx context.Context, walletID int64, holdID int64) (dto.HoldResponse, error)
+	CaptureHold(ctx context.Context, idempotencyKey string, walletID int64, holdID int64, req dto.CaptureHoldRequest) (resp dto.CaptureHoldResponse, statusCode int, err error)
+	VoidHold(ctx context.Context, idempotencyKey string, walletID int64, holdID int64) (resp dto.HoldResponse, statusCode int, err error)
+	ExpireHolds(ctx context.Context) (int, error)
+	ClearDeposits(ctx context.Context) (int, error)
+	CreateAdjustment(ctx context.Context, idempotencyKey string, req dto.AdjustmentRequest) (resp dto.ApprovalResponse, statusCode int, err error)
+	ListApprovals(ctx context.Context, req dto.ApprovalListRequest) (dto.ApprovalListResponse, error)
+	GetApproval(ctx context.Context, approvalID int64) (dto.ApprovalResponse, error)
+	ApproveRequest(ctx context.Context, approvalID int64) (dto.ApprovalResponse, error)
+	RejectRequest(ctx context.Context, approvalID int64, req dto.RejectApprovalRequest) (dto.ApprovalResponse, error)
+	ExpireApprovals(ctx context.Context) (int, error)
+	PurgeIdempotencyKeys(ctx context.Context) (int, error)
+	ReconcileBalances(ctx context.Context) (int, error)
 }
 
 type TransactionServiceImpl struct {
-	db              *gorm.DB
-	redis           *redis.Client
-	transactionRepo repository.TransactionRepository
-	walletRepo      repository.WalletRepository
+	db               *gorm.DB
+	idempotencyStore repository.IdempotencyStore
+	transactionRepo  repository.TransactionRepository
+	walletRepo       repository.WalletRepository
+	journalRepo      repository.JournalRepository
+	holdRepo         repository.HoldRepository
+	clearingRepo     repository.DepositClearingRepository
+	fxQuoteRepo      repository.FXQuoteRepository
+	rateProvider     repository.RateProvider
+	feeScheduleRepo  repository.FeeScheduleRepository
+	limitRepo        repository.LimitRepository
+	memberRepo       repository.WalletMemberRepository
+	principalRepo    repository.PrincipalRepository
+	approvalRepo     repository.ApprovalRepository
+
+	holdDefaultTTL       time.Duration
+	depositClearingDelay time.Duration
+	fxQuoteTTL           time.Duration
+	fxSpread             decimal.Decimal
+	approvalThresholds   map[string]decimal.Decimal
+	approvalTTL          time.Duration
 }
 
-func NewTransactionService(db *gorm.DB, redis *redis.Client, repo repository.TransactionRepository, walletRepo repository.WalletRepository) TransactionService {
-	return &TransactionServiceImpl{db: db, redis: redis, transactionRepo: repo, walletRepo: walletRepo}
+func NewTransactionService(db *gorm.DB, idempotencyStore repository.IdempotencyStore, repo repository.Repository, config *configs.Config) TransactionService {
+	return &TransactionServiceImpl{
+		db:               db,
+		idempotencyStore: idempotencyStore,
+		transactionRepo:  repo.Transaction,
+		walletRepo:       repo.Wallet,
+		journalRepo:      repo.Journal,
+		holdRepo:         repo.Hold,
+		clearingRepo:     repo.Clearing,
+		fxQuoteRepo:      repo.FXQuote,
+		rateProvider:     repo.Rate,
+		feeScheduleRepo:  repo.FeeSchedule,
+		limitRepo:        repo.Limit,
+		memberRepo:       repo.Member,
+		principalRepo:    repo.Principal,
+		approvalRepo:     repo.Approval,
+
+		holdDefaultTTL:       config.HoldDefaultTTL,
+		depositClearingDelay: config.DepositClearingDelay,
+		fxQuoteTTL:           config.FXQuoteTTL,
+		fxSpread:             config.FXSpread,
+		approvalThresholds:   config.ApprovalTransferThresholds,
+		approvalTTL:          config.ApprovalTTL,
+	}
 }
 
 func (s *TransactionServiceImpl) createTransactionWithUpdateBalance(ctx context.Context, tx *gorm.DB, transaction model.Transaction, newBalance decimal.Decimal) (int64, error) {
+	// Stored on the row so a statement can be reconciled entry by entry
+	transaction.BalanceAfter = &newBalance
+
 	transactionID, err := s.transactionRepo.CreateTransaction(ctx, tx, transaction)
 	if err != nil {
 		log.Printf("creating transaction, err: %+v", err)
@@ -48,217 +111,344 @@ func (s *TransactionServiceImpl) createTransactionWithUpdateBalance(ctx context.
 	return transactionID, nil
 }
 
-func (s *TransactionServiceImpl) Withdraw(ctx context.Context, idempotencyKey string, walletID int64, req dto.AmountRequest) (resp dto.TransactionResponse, err error) {
-	// Check double request
-	val, err := s.redis.Exists(ctx, idempotencyKey).Result()
-	if val == 1 {
-		return dto.TransactionResponse{}, errors.New("Double Request")
+// lockWallets locks every customer wallet in ascending ID order, so concurrent operations
+// on the same wallets can't deadlock. System wallets are read without a lock, postings only
+// append to them, so they don't serialize every operation of their currency. Missing or
+// closed wallets are absent from the result.
+func (s *TransactionServiceImpl) lockWallets(ctx context.Context, tx *gorm.DB, walletIDs ...int64) (map[int64]*model.Wallet, error) {
+	ids := append([]int64(nil), walletIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	systemWallets, err := s.walletRepo.GetListSystemByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("error listing system wallets, err: %+v", err)
+		return nil, err
 	}
 
-	// Set Idempotency Key
-	s.redis.Set(ctx, idempotencyKey, true, 24*time.Hour).Err()
+	wallets := make(map[int64]*model.Wallet, len(ids))
+	for i := range systemWallets {
+		wallets[systemWallets[i].ID] = &systemWallets[i]
+	}
+	for _, id := range ids {
+		if _, ok := wallets[id]; ok {
+			continue
+		}
 
-	curretWallet, err := s.walletRepo.FindByID(ctx, walletID)
-	if err != nil {
-		log.Printf("error wallet find by id, err: %+v", err)
-		return dto.TransactionResponse{}, err
+		wallet, err := s.walletRepo.FindByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			log.Printf("error wallet find by id for update, err: %+v", err)
+			return nil, err
+		}
+		if wallet != nil {
+			wallets[id] = wallet
+		}
 	}
+	return wallets, nil
+}
+
+func (s *TransactionServiceImpl) Withdraw(ctx context.Context, idempotencyKey string, walletID int64, req dto.AmountRequest) (resp dto.TransactionResponse, statusCode int, err error) {
+	return withIdempotency(ctx, s.db, s.idempotencyStore, walletID, idempotencyOperationWithdraw, idempotencyKey, req, respondWith[dto.TransactionResponse](http.StatusOK), func(tx *gorm.DB) (dto.TransactionResponse, error) {
+		return s.withdraw(ctx, tx, walletID, req)
+	})
+}
 
+func (s *TransactionServiceImpl) withdraw(ctx context.Context, tx *gorm.DB, walletID int64, req dto.AmountRequest) (dto.TransactionResponse, error) {
 	if req.Amount.LessThanOrEqual(decimal.Zero) {
-		log.Printf("attempting to withdraw 0 amount, err: %+v", err)
-		return dto.TransactionResponse{}, errors.New("attempting to 0 amount")
+		log.Printf("attempting to withdraw 0 amount")
+		return dto.TransactionResponse{}, apperror.ErrInvalidAmount
 	}
 
-	if req.Amount.GreaterThan(curretWallet.CurrentBalance) {
-		log.Printf("attempting to withdraw more than available balance, err: %+v", err)
-		return dto.TransactionResponse{}, errors.New("attempting to withdraw more than available balance")
+	currency, err := s.walletCurrency(ctx, walletID)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
+	if err := checkPrecision(req.Amount, currency); err != nil {
+		return dto.TransactionResponse{}, err
 	}
 
-	// begin transaction
-	tx := s.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return dto.TransactionResponse{}, tx.Error
+	fee, err := s.feeFor(ctx, constant.TransactionTypeWithdraw, currency, req.Amount)
+	if err != nil {
+		return dto.TransactionResponse{}, err
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		} else if tx.Error != nil {
-			tx.Rollback()
-		}
-	}()
+	cashOutID, err := s.systemWalletID(ctx, constant.SystemWalletCashOut, currency)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
 
-	// create transaction
-	transaction := model.Transaction{
-		ID:        0,
-		WalletID:  curretWallet.ID,
-		Type:      constant.TransactionTypeWithdraw,
-		IsDebit:   false,
-		Value:     req.Amount,
-		Remarks:   "Withdraw",
-		CreatedAt: time.Now(),
+	feesID, err := s.systemWalletID(ctx, constant.SystemWalletFees, currency)
+	if err != nil {
+		return dto.TransactionResponse{}, err
 	}
-	newBalance := curretWallet.CurrentBalance.Sub(req.Amount)
-	transactionID, err := s.createTransactionWithUpdateBalance(ctx, tx, transaction, newBalance)
+
+	wallets, err := s.lockWallets(ctx, tx, walletID, cashOutID, feesID)
 	if err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
-	err = tx.Commit().Error
+	wallet := customerWallet(wallets, walletID)
+	if wallet == nil {
+		return dto.TransactionResponse{}, apperror.ErrWalletNotFound
+	}
+
+	if req.Amount.Add(fee).GreaterThan(wallet.AvailableBalance()) {
+		log.Printf("attempting to withdraw more than available balance, wallet: %d", walletID)
+		return dto.TransactionResponse{}, apperror.ErrInsufficientFunds
+	}
+
+	if err := s.checkLimits(ctx, tx, wallet, constant.TransactionTypeWithdraw, req.Amount); err != nil {
+		return dto.TransactionResponse{}, err
+	}
+	if err := s.checkSpendLimit(ctx, tx, wallet, req.Amount, nil); err != nil {
+		return dto.TransactionResponse{}, err
+	}
+
+	// Money leaves the wallet into the cash-out account, the fee into the fees account
+	legs := []ledgerLeg{
+		{Wallet: wallet, Counterparty: wallets[cashOutID], IsDebit: false, Amount: req.Amount, Remarks: "Withdraw"},
+		{Wallet: wallets[cashOutID], Counterparty: wallet, IsDebit: true, Amount: req.Amount, Remarks: "Withdraw"},
+	}
+	legs = append(legs, feeLegs(wallet, wallets[feesID], fee, "Withdraw")...)
+
+	_, transactionIDs, err := s.postJournalEntry(ctx, tx, model.JournalEntry{
+		Type:      constant.TransactionTypeWithdraw,
+		Amount:    req.Amount,
+		FeeAmount: fee,
+		Remarks:   "Withdraw",
+	}, legs)
 	if err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
 	return dto.TransactionResponse{
-		TransactionID: transactionID,
+		TransactionID: transactionIDs[0],
+		Fee:           dto.FeeAmount(fee),
 	}, nil
 }
 
-func (s *TransactionServiceImpl) Deposit(ctx context.Context, idempotencyKey string, walletID int64, req dto.AmountRequest) (resp dto.TransactionResponse, err error) {
-	// Check double request
-	val, err := s.redis.Exists(ctx, idempotencyKey).Result()
-	if val == 1 {
-		return dto.TransactionResponse{}, errors.New("Double Request")
-	}
-	// Set Idempotency Key
-	s.redis.Set(ctx, idempotencyKey, true, 24*time.Hour).Err()
+func (s *TransactionServiceImpl) Deposit(ctx context.Context, idempotencyKey string, walletID int64, req dto.AmountRequest) (resp dto.TransactionResponse, statusCode int, err error) {
+	return withIdempotency(ctx, s.db, s.idempotencyStore, walletID, idempotencyOperationDeposit, idempotencyKey, req, respondWith[dto.TransactionResponse](http.StatusOK), func(tx *gorm.DB) (dto.TransactionResponse, error) {
+		return s.deposit(ctx, tx, walletID, req)
+	})
+}
 
+func (s *TransactionServiceImpl) deposit(ctx context.Context, tx *gorm.DB, walletID int64, req dto.AmountRequest) (dto.TransactionResponse, error) {
 	if req.Amount.LessThanOrEqual(decimal.Zero) {
-		log.Printf("attempting to deposit 0 or less, err: %+v", err)
-		return dto.TransactionResponse{}, errors.New("attempting to deposit 0 or less")
+		log.Printf("attempting to deposit 0 or less")
+		return dto.TransactionResponse{}, apperror.ErrInvalidAmount
 	}
 
-	curretWallet, err := s.walletRepo.FindByID(ctx, walletID)
+	currency, err := s.walletCurrency(ctx, walletID)
 	if err != nil {
-		log.Printf("error wallet find by id, err: %+v", err)
+		return dto.TransactionResponse{}, err
+	}
+	if err := checkPrecision(req.Amount, currency); err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
-	// begin transaction
-	tx := s.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return dto.TransactionResponse{}, tx.Error
+	cashInID, err := s.systemWalletID(ctx, constant.SystemWalletCashIn, currency)
+	if err != nil {
+		return dto.TransactionResponse{}, err
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		} else if tx.Error != nil {
-			tx.Rollback()
-		}
-	}()
+	wallets, err := s.lockWallets(ctx, tx, walletID, cashInID)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
 
-	transaction := model.Transaction{
-		ID:        0,
-		WalletID:  walletID,
-		Type:      constant.TransactionTypeDeposit,
-		IsDebit:   true,
-		Value:     req.Amount,
-		Remarks:   "Deposit",
-		CreatedAt: time.Now(),
+	wallet := customerWallet(wallets, walletID)
+	if wallet == nil {
+		return dto.TransactionResponse{}, apperror.ErrWalletNotFound
 	}
-	newBalance := curretWallet.CurrentBalance.Add(req.Amount)
 
-	transactionID, err := s.createTransactionWithUpdateBalance(ctx, tx, transaction, newBalance)
+	// Money enters the wallet from the cash-in account
+	entryID, transactionIDs, err := s.postJournalEntry(ctx, tx, model.JournalEntry{
+		Type:    constant.TransactionTypeDeposit,
+		Amount:  req.Amount,
+		Remarks: "Deposit",
+	}, []ledgerLeg{
+		{Wallet: wallet, Counterparty: wallets[cashInID], IsDebit: true, Amount: req.Amount, Remarks: "Deposit"},
+		{Wallet: wallets[cashInID], Counterparty: wallet, IsDebit: false, Amount: req.Amount, Remarks: "Deposit"},
+	})
 	if err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
-	err = tx.Commit().Error
+	clearsAt, err := s.startDepositClearing(ctx, tx, wallet, entryID, req.Amount)
 	if err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
 	return dto.TransactionResponse{
-		TransactionID: transactionID,
+		TransactionID: transactionIDs[0],
+		ClearsAt:      clearsAt,
 	}, nil
 }
 
-func (s *TransactionServiceImpl) Transfer(ctx context.Context, idempotencyKey string, walletID int64, req dto.TransferRequest) (resp dto.TransactionResponse, err error) {
-	// Check double request
-	val, err := s.redis.Exists(ctx, idempotencyKey).Result()
-	if val == 1 {
-		return dto.TransactionResponse{}, errors.New("Double Request")
+func (s *TransactionServiceImpl) Transfer(ctx context.Context, idempotencyKey string, walletID int64, req dto.TransferRequest) (resp dto.TransactionResponse, statusCode int, err error) {
+	// Accepted but not posted when the transfer waits for a second approver
+	queued := func(resp dto.TransactionResponse) bool { return resp.ApprovalID != nil }
+	return withIdempotency(ctx, s.db, s.idempotencyStore, walletID, idempotencyOperationTransfer, idempotencyKey, req, respondAccepted(queued), func(tx *gorm.DB) (dto.TransactionResponse, error) {
+		return s.transfer(ctx, tx, walletID, req)
+	})
+}
+
+// transfer posts the transfer right away, or queues it for approval when its amount is
+// above the approval threshold
+func (s *TransactionServiceImpl) transfer(ctx context.Context, tx *gorm.DB, walletID int64, req dto.TransferRequest) (dto.TransactionResponse, error) {
+	if req.ReceiverWalletID == walletID {
+		return dto.TransactionResponse{}, apperror.ErrSameWalletTransfer
 	}
-	// Set Idempotency Key
-	s.redis.Set(ctx, idempotencyKey, true, 24*time.Hour).Err()
 
-	curretWallet, err := s.walletRepo.FindByID(ctx, walletID)
+	amount := req.Amount
+	var quote *model.FXQuote
+	if req.QuoteID != nil {
+		found, err := s.fxQuoteRepo.FindByID(ctx, *req.QuoteID)
+		if err != nil {
+			log.Printf("error fx quote find by id, err: %+v", err)
+			return dto.TransactionResponse{}, err
+		}
+		if found == nil || found.WalletID != walletID {
+			return dto.TransactionResponse{}, apperror.ErrQuoteNotFound
+		}
+		quote = found
+		amount = quote.SourceAmount
+	}
+
+	currency, err := s.walletCurrency(ctx, walletID)
 	if err != nil {
-		log.Printf("error wallet find by id, err: %+v", err)
 		return dto.TransactionResponse{}, err
 	}
+	if s.requiresApproval(currency, amount) {
+		return s.queueTransfer(ctx, tx, walletID, amount, quote, req)
+	}
+	return s.postTransfer(ctx, tx, walletID, req)
+}
 
-	if req.Amount.LessThanOrEqual(decimal.Zero) {
-		log.Printf("attempting to transfer 0 amount, err: %+v", err)
-		return dto.TransactionResponse{}, errors.New("attempting to 0 amount")
+// postTransfer moves the money, it runs for a transfer under the threshold and once a
+// queued transfer is approved
+func (s *TransactionServiceImpl) postTransfer(ctx context.Context, tx *gorm.DB, walletID int64, req dto.TransferRequest) (dto.TransactionResponse, error) {
+	if req.QuoteID != nil {
+		return s.transferWithQuote(ctx, tx, walletID, req)
 	}
 
-	if req.Amount.GreaterThan(curretWallet.CurrentBalance) {
-		log.Printf("attempting to transfer more than available balance, err: %+v", err)
-		return dto.TransactionResponse{}, errors.New("attempting to transfer more than available balance")
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		log.Printf("attempting to transfer 0 amount")
+		return dto.TransactionResponse{}, apperror.ErrInvalidAmount
 	}
 
-	// begin transaction
-	tx := s.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return dto.TransactionResponse{}, tx.Error
+	currency, err := s.walletCurrency(ctx, walletID)
+	if err != nil {
+		return dto.TransactionResponse{}, err
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		} else if tx.Error != nil {
-			tx.Rollback()
-		}
-	}()
+	fee, err := s.feeFor(ctx, constant.TransactionTypeTransfer, currency, req.Amount)
+	if err != nil {
+		return dto.TransactionResponse{}, err
+	}
 
-	// create transaction
-	senderTransaction := model.Transaction{
-		ID:        0,
-		WalletID:  curretWallet.ID,
-		Type:      constant.TransactionTypeTransfer,
-		IsDebit:   false,
-		Value:     req.Amount,
-		Remarks:   "Transfer - Send",
-		CreatedAt: time.Now(),
+	feesID, err := s.systemWalletID(ctx, constant.SystemWalletFees, currency)
+	if err != nil {
+		return dto.TransactionResponse{}, err
 	}
-	newSenderBalance := curretWallet.CurrentBalance.Sub(req.Amount)
-	senderTransactionID, err := s.createTransactionWithUpdateBalance(ctx, tx, senderTransaction, newSenderBalance)
+
+	wallets, err := s.lockWallets(ctx, tx, walletID, req.ReceiverWalletID, feesID)
 	if err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
-	// create transaction
-	receiverTransaction := model.Transaction{
-		ID:        0,
-		WalletID:  req.ReceiverWalletID,
-		Type:      constant.TransactionTypeTransfer,
-		IsDebit:   true,
-		Value:     req.Amount,
-		Remarks:   "Transfer - Receive",
-		CreatedAt: time.Now(),
+	wallet := customerWallet(wallets, walletID)
+	if wallet == nil {
+		return dto.TransactionResponse{}, apperror.ErrWalletNotFound
 	}
 
-	receiverWallet, err := s.walletRepo.FindByID(ctx, req.ReceiverWalletID)
-	if err != nil {
-		log.Printf("error wallet find by id, err: %+v", err)
+	receiverWallet := customerWallet(wallets, req.ReceiverWalletID)
+	if receiverWallet == nil {
+		return dto.TransactionResponse{}, apperror.ErrWalletNotFound.WithMessage("receiver wallet not found")
+	}
+
+	// Moving money between currencies needs a quote, see transferWithQuote
+	if receiverWallet.Currency != wallet.Currency {
+		return dto.TransactionResponse{}, apperror.ErrCurrencyMismatch
+	}
+	if err := checkPrecision(req.Amount, wallet.Currency); err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
-	newReceiverBalance := receiverWallet.CurrentBalance.Add(req.Amount)
-	_, err = s.createTransactionWithUpdateBalance(ctx, tx, receiverTransaction, newReceiverBalance)
-	if err != nil {
+	if req.Amount.Add(fee).GreaterThan(wallet.AvailableBalance()) {
+		log.Printf("attempting to transfer more than available balance, wallet: %d", walletID)
+		return dto.TransactionResponse{}, apperror.ErrInsufficientFunds
+	}
+
+	if err := s.checkLimits(ctx, tx, wallet, constant.TransactionTypeTransfer, req.Amount); err != nil {
+		return dto.TransactionResponse{}, err
+	}
+	if err := s.checkSpendLimit(ctx, tx, wallet, req.Amount, nil); err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
-	err = tx.Commit().Error
+	// All legs share the journal entry, its ID is the transfer ID. The sender pays the fee.
+	legs := []ledgerLeg{
+		{Wallet: wallet, Counterparty: receiverWallet, IsDebit: false, Amount: req.Amount, Remarks: "Transfer - Send"},
+		{Wallet: receiverWallet, Counterparty: wallet, IsDebit: true, Amount: req.Amount, Remarks: "Transfer - Receive"},
+	}
+	legs = append(legs, feeLegs(wallet, wallets[feesID], fee, "Transfer")...)
+
+	transferID, transactionIDs, err := s.postJournalEntry(ctx, tx, model.JournalEntry{
+		Type:      constant.TransactionTypeTransfer,
+		Amount:    req.Amount,
+		FeeAmount: fee,
+		Remarks:   "Transfer",
+	}, legs)
 	if err != nil {
 		return dto.TransactionResponse{}, err
 	}
 
 	return dto.TransactionResponse{
-			TransactionID: senderTransactionID,
+			TransactionID: transactionIDs[0],
+			TransferID:    transferID,
+			Fee:           dto.FeeAmount(fee),
 		},
 		nil
 }
+
+// GetTransfer returns both legs of a transfer, only to a wallet taking part in it
+func (s *TransactionServiceImpl) GetTransfer(ctx context.Context, walletID int64, transferID int64) (dto.TransferDetailResponse, error) {
+	entry, err := s.journalRepo.FindByID(ctx, transferID)
+	if err != nil {
+		return dto.TransferDetailResponse{}, err
+	}
+	if entry == nil || entry.Type != constant.TransactionTypeTransfer {
+		return dto.TransferDetailResponse{}, apperror.ErrTransferNotFound
+	}
+
+	legs, err := s.transactionRepo.GetListTransactionByJournalEntryID(ctx, transferID)
+	if err != nil {
+		return dto.TransferDetailResponse{}, err
+	}
+
+	isParty := false
+	for _, leg := range legs {
+		if leg.WalletID == walletID {
+			isParty = true
+		}
+	}
+	if !isParty {
+		return dto.TransferDetailResponse{}, apperror.ErrTransferNotFound
+	}
+
+	return dto.NewTransferDetailResponse(*entry, legs), nil
+}
+
+// GetTransaction returns a posting only to the wallet it was posted to
+func (s *TransactionServiceImpl) GetTransaction(ctx context.Context, walletID int64, transactionID int64) (dto.TransactionDetailResponse, error) {
+	transaction, err := s.transactionRepo.FindByID(ctx, transactionID)
+	if err != nil {
+		return dto.TransactionDetailResponse{}, err
+	}
+	if transaction == nil || transaction.WalletID != walletID {
+		return dto.TransactionDetailResponse{}, apperror.ErrTransactionNotFound
+	}
+
+	return dto.NewTransactionDetailResponse(*transaction), nil
+}
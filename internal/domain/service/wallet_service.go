@@ -2,42 +2,387 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
 	"github.com/krisnadwipayana07/restful-fintech/pkg/dto"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type WalletService interface {
-	WalletHistory(ctx context.Context, id int64) ([]dto.TransactionDetailResponse, error)
-	WalletBalance(ctx context.Context, id int64) (decimal.Decimal, error)
+	CreateWallet(ctx context.Context, owner Principal, req dto.CreateWalletRequest) (dto.WalletResponse, error)
+	GetWallet(ctx context.Context, id int64) (dto.WalletResponse, error)
+	RenameWallet(ctx context.Context, id int64, req dto.RenameWalletRequest) (dto.WalletResponse, error)
+	CloseWallet(ctx context.Context, id int64) error
+	WalletHistory(ctx context.Context, id int64, req dto.WalletHistoryRequest) (dto.TransactionHistoryResponse, error)
+	WalletBalance(ctx context.Context, id int64) (dto.WalletBalanceResponse, error)
+	ListMembers(ctx context.Context, id int64) (dto.WalletMembersResponse, error)
+	SaveMember(ctx context.Context, id int64, customerID int64, req dto.SaveWalletMemberRequest) (dto.WalletMemberResponse, error)
+	RemoveMember(ctx context.Context, id int64, customerID int64) error
 }
 
 type WalletServiceImpl struct {
+	db              *gorm.DB
 	transactionRepo repository.TransactionRepository
 	walletRepo      repository.WalletRepository
+	principalRepo   repository.PrincipalRepository
+	customerRepo    repository.CustomerRepository
+	memberRepo      repository.WalletMemberRepository
 }
 
-func NewWalletService(repo repository.TransactionRepository, walletRepo repository.WalletRepository) WalletService {
-	return &WalletServiceImpl{transactionRepo: repo, walletRepo: walletRepo}
+func NewWalletService(db *gorm.DB, repo repository.Repository) WalletService {
+	return &WalletServiceImpl{
+		db:              db,
+		transactionRepo: repo.Transaction,
+		walletRepo:      repo.Wallet,
+		principalRepo:   repo.Principal,
+		customerRepo:    repo.Customer,
+		memberRepo:      repo.Member,
+	}
 }
 
-func (s *WalletServiceImpl) WalletHistory(ctx context.Context, id int64) ([]dto.TransactionDetailResponse, error) {
-	data, err := s.transactionRepo.GetListTransactionByWalletID(ctx, id)
+// CreateWallet opens a wallet for the customer of the owner, who becomes its first owner
+// member, or linked to the owner itself when it acts for no customer. The insecure header
+// mode has no owner, the wallet is left unowned.
+func (s *WalletServiceImpl) CreateWallet(ctx context.Context, owner Principal, req dto.CreateWalletRequest) (dto.WalletResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return dto.WalletResponse{}, apperror.ErrInvalidRequest.WithMessage("wallet name is required")
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = constant.DefaultCurrency
+	}
+
+	now := time.Now()
+	wallet := model.Wallet{
+		Name:             name,
+		Currency:         currency,
+		Tier:             constant.WalletTierStandard,
+		CustomerID:       owner.CustomerID,
+		CurrentBalance:   decimal.Zero,
+		HeldBalance:      decimal.Zero,
+		UnclearedBalance: decimal.Zero,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	err := runInTransaction(ctx, s.db, func(tx *gorm.DB) error {
+		wallet.ID = 0
+		if err := s.walletRepo.CreateWallet(ctx, tx, &wallet); err != nil {
+			return err
+		}
+		if owner.CustomerID != nil {
+			return s.memberRepo.SaveMember(ctx, tx, model.WalletMember{
+				WalletID:   wallet.ID,
+				CustomerID: *owner.CustomerID,
+				Role:       constant.WalletRoleOwner,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			})
+		}
+		if owner.ID == 0 {
+			return nil
+		}
+		return s.principalRepo.AddWallet(ctx, tx, owner.ID, wallet.ID)
+	})
 	if err != nil {
-		return nil, err
+		log.Printf("error creating wallet, err: %+v", err)
+		return dto.WalletResponse{}, err
+	}
+
+	return dto.NewWalletResponse(wallet), nil
+}
+
+func (s *WalletServiceImpl) GetWallet(ctx context.Context, id int64) (dto.WalletResponse, error) {
+	wallet, err := s.walletRepo.FindByID(ctx, id)
+	if err != nil {
+		return dto.WalletResponse{}, err
+	}
+	if wallet == nil {
+		return dto.WalletResponse{}, apperror.ErrWalletNotFound
+	}
+	return dto.NewWalletResponse(*wallet), nil
+}
+
+func (s *WalletServiceImpl) RenameWallet(ctx context.Context, id int64, req dto.RenameWalletRequest) (dto.WalletResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return dto.WalletResponse{}, apperror.ErrInvalidRequest.WithMessage("wallet name is required")
+	}
+
+	wallet, err := s.walletRepo.FindByID(ctx, id)
+	if err != nil {
+		return dto.WalletResponse{}, err
+	}
+	if wallet == nil {
+		return dto.WalletResponse{}, apperror.ErrWalletNotFound
+	}
+
+	if err := s.walletRepo.UpdateName(ctx, id, name); err != nil {
+		log.Printf("error renaming wallet, err: %+v", err)
+		return dto.WalletResponse{}, err
+	}
+
+	return s.GetWallet(ctx, id)
+}
+
+// CloseWallet checks the balances under the wallet lock, so money posted concurrently
+// either lands before the check or finds the wallet closed
+func (s *WalletServiceImpl) CloseWallet(ctx context.Context, id int64) error {
+	return runInTransaction(ctx, s.db, func(tx *gorm.DB) error {
+		wallet, err := s.walletRepo.FindByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			log.Printf("error wallet find by id for update, err: %+v", err)
+			return err
+		}
+		if wallet == nil || wallet.IsSystem() {
+			return apperror.ErrWalletNotFound
+		}
+
+		// Money must be moved out before closing, otherwise it becomes unreachable
+		if !wallet.CurrentBalance.IsZero() || !wallet.HeldBalance.IsZero() || !wallet.UnclearedBalance.IsZero() {
+			return apperror.ErrWalletNotEmpty
+		}
+
+		if err := s.walletRepo.CloseWallet(ctx, tx, id); err != nil {
+			log.Printf("error closing wallet, err: %+v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *WalletServiceImpl) WalletHistory(ctx context.Context, id int64, req dto.WalletHistoryRequest) (dto.TransactionHistoryResponse, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = dto.DefaultHistoryLimit
+	}
+
+	filter := repository.TransactionFilter{
+		Type:      req.Type,
+		IsDebit:   req.IsDebit,
+		MinAmount: req.MinAmount,
+		MaxAmount: req.MaxAmount,
+		From:      req.From,
+		To:        req.To,
+		// One extra row tells whether there is a next page
+		Limit: limit + 1,
 	}
-	return dto.NewTransactionListResponse(data), nil
+
+	if req.Cursor != "" {
+		cursor, err := decodeHistoryCursor(req.Cursor)
+		if err != nil {
+			return dto.TransactionHistoryResponse{}, apperror.ErrInvalidRequest.WithMessage("invalid cursor")
+		}
+		filter.After = &cursor
+	}
+
+	data, err := s.transactionRepo.GetListTransactionByWalletID(ctx, id, filter)
+	if err != nil {
+		return dto.TransactionHistoryResponse{}, err
+	}
+
+	resp := dto.TransactionHistoryResponse{}
+	if len(data) > limit {
+		data = data[:limit]
+		last := data[len(data)-1]
+		resp.NextCursor = encodeHistoryCursor(repository.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	resp.Data = dto.NewTransactionListResponse(data)
+
+	return resp, nil
+}
+
+// History cursors are opaque to clients, they carry the (created_at, id) of the last row served
+func encodeHistoryCursor(cursor repository.TransactionCursor) string {
+	raw := fmt.Sprintf("%d:%d", cursor.CreatedAt.UnixNano(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeHistoryCursor(value string) (repository.TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return repository.TransactionCursor{}, err
+	}
+
+	var createdAt, id int64
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &createdAt, &id); err != nil {
+		return repository.TransactionCursor{}, err
+	}
+
+	return repository.TransactionCursor{CreatedAt: time.Unix(0, createdAt), ID: id}, nil
 }
 
-func (s *WalletServiceImpl) WalletBalance(ctx context.Context, id int64) (decimal.Decimal, error) {
+func (s *WalletServiceImpl) WalletBalance(ctx context.Context, id int64) (dto.WalletBalanceResponse, error) {
 	data, err := s.walletRepo.FindByID(ctx, id)
 	if err != nil {
-		return decimal.Zero, err
+		return dto.WalletBalanceResponse{}, err
 	}
 	if data == nil {
-		return decimal.Zero, errors.New("wallet not found")
+		return dto.WalletBalanceResponse{}, apperror.ErrWalletNotFound
+	}
+	return dto.NewWalletBalanceResponse(*data), nil
+}
+
+func (s *WalletServiceImpl) ListMembers(ctx context.Context, id int64) (dto.WalletMembersResponse, error) {
+	members, err := s.memberRepo.GetListByWalletID(ctx, id)
+	if err != nil {
+		log.Printf("error listing wallet members, err: %+v", err)
+		return dto.WalletMembersResponse{}, err
+	}
+	return dto.NewWalletMembersResponse(id, members), nil
+}
+
+// SaveMember adds the customer to the wallet or changes its role, the owners are locked so
+// the last one can't be demoted by two concurrent requests
+func (s *WalletServiceImpl) SaveMember(ctx context.Context, id int64, customerID int64, req dto.SaveWalletMemberRequest) (dto.WalletMemberResponse, error) {
+	wallet, err := s.walletRepo.FindByID(ctx, id)
+	if err != nil {
+		return dto.WalletMemberResponse{}, err
+	}
+	if wallet == nil {
+		return dto.WalletMemberResponse{}, apperror.ErrWalletNotFound
+	}
+
+	if req.SpendLimit != nil {
+		if err := checkPrecision(*req.SpendLimit, wallet.Currency); err != nil {
+			return dto.WalletMemberResponse{}, err
+		}
+	}
+
+	customer, err := s.customerRepo.FindByID(ctx, customerID)
+	if err != nil {
+		return dto.WalletMemberResponse{}, err
+	}
+	if customer == nil {
+		return dto.WalletMemberResponse{}, apperror.ErrCustomerNotFound
+	}
+
+	now := time.Now()
+	member := model.WalletMember{
+		WalletID:   id,
+		CustomerID: customerID,
+		Role:       req.Role,
+		SpendLimit: req.SpendLimit,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	err = runInTransaction(ctx, s.db, func(tx *gorm.DB) error {
+		locked, err := s.lockWallet(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if req.Role != constant.WalletRoleOwner {
+			if err := s.checkOtherOwner(ctx, tx, id, customerID); err != nil {
+				return err
+			}
+		}
+		if err := s.memberRepo.SaveMember(ctx, tx, member); err != nil {
+			return err
+		}
+		return s.syncWalletCustomer(ctx, tx, locked)
+	})
+	if errors.Is(err, apperror.ErrLastWalletOwner) {
+		return dto.WalletMemberResponse{}, err
+	}
+	if err != nil {
+		log.Printf("error saving wallet member, err: %+v", err)
+		return dto.WalletMemberResponse{}, err
+	}
+
+	saved, err := s.memberRepo.FindMember(ctx, id, customerID)
+	if err != nil {
+		return dto.WalletMemberResponse{}, err
+	}
+	if saved == nil {
+		return dto.WalletMemberResponse{}, apperror.ErrMemberNotFound
+	}
+	return dto.NewWalletMemberResponse(*saved), nil
+}
+
+func (s *WalletServiceImpl) RemoveMember(ctx context.Context, id int64, customerID int64) error {
+	member, err := s.memberRepo.FindMember(ctx, id, customerID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return apperror.ErrMemberNotFound
+	}
+
+	err = runInTransaction(ctx, s.db, func(tx *gorm.DB) error {
+		locked, err := s.lockWallet(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.checkOtherOwner(ctx, tx, id, customerID); err != nil {
+			return err
+		}
+		if err := s.memberRepo.DeleteMember(ctx, tx, id, customerID); err != nil {
+			return err
+		}
+		return s.syncWalletCustomer(ctx, tx, locked)
+	})
+	if errors.Is(err, apperror.ErrLastWalletOwner) {
+		return err
+	}
+	if err != nil {
+		log.Printf("error removing wallet member, err: %+v", err)
+		return err
+	}
+	return nil
+}
+
+// lockWallet locks the wallet before its members, the order the postings lock in
+func (s *WalletServiceImpl) lockWallet(ctx context.Context, tx *gorm.DB, id int64) (*model.Wallet, error) {
+	wallet, err := s.walletRepo.FindByIDForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil || wallet.IsSystem() {
+		return nil, apperror.ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+// syncWalletCustomer keeps the customer of the wallet one of its owners after the owners
+// changed, the longest standing one when the customer is no longer an owner. A wallet
+// without owner members is left alone.
+func (s *WalletServiceImpl) syncWalletCustomer(ctx context.Context, tx *gorm.DB, wallet *model.Wallet) error {
+	owners, err := s.memberRepo.LockOwners(ctx, tx, wallet.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(owners) == 0 {
+		return nil
+	}
+	for _, owner := range owners {
+		if wallet.CustomerID != nil && owner.CustomerID == *wallet.CustomerID {
+			return nil
+		}
+	}
+	return s.walletRepo.UpdateCustomerID(ctx, tx, wallet.ID, &owners[0].CustomerID)
+}
+
+// checkOtherOwner fails when customerID is the only owner left, a customer-owned wallet
+// can't lose all its owners. Wallets without owner members are left alone.
+func (s *WalletServiceImpl) checkOtherOwner(ctx context.Context, tx *gorm.DB, walletID int64, customerID int64) error {
+	owners, err := s.memberRepo.LockOwners(ctx, tx, walletID)
+	if err != nil {
+		return err
+	}
+	if len(owners) == 1 && owners[0].CustomerID == customerID {
+		return apperror.ErrLastWalletOwner
 	}
-	return data.CurrentBalance, nil
+	return nil
 }
@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/krisnadwipayana07/restful-fintech/configs"
 	"github.com/krisnadwipayana07/restful-fintech/internal/delivery/http"
+	"github.com/krisnadwipayana07/restful-fintech/internal/delivery/job"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/repository"
 	"github.com/krisnadwipayana07/restful-fintech/internal/domain/service"
 	"github.com/krisnadwipayana07/restful-fintech/internal/infrastructure"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -22,31 +25,42 @@ func main() {
 	e := echo.New()
 	e.Use(middleware.Logger())
 
+	if config.AuthInsecureHeader {
+		e.Logger.Warn("AUTH_INSECURE_HEADER is set, X-Wallet-ID is trusted without authentication")
+	}
+
 	// Initialize dependencies
 	db, err := infrastructure.NewDatabaseConnection(&config)
 	if err != nil {
 		panic(err)
 	}
 
-	redis, err := infrastructure.InitRedisConnection(&config)
-	if err != nil {
-		panic(err)
+	// Redis is only required when it backs the idempotency store or the signature nonces
+	var redisClient *redis.Client
+	if config.IdempotencyStore == repository.IdempotencyStoreRedis || config.RequestSigning {
+		redisClient, err = infrastructure.InitRedisConnection(&config)
+		if err != nil {
+			panic(err)
+		}
 	}
 
 	// Initialize repository
-	repo, err := repository.New(db)
+	repo, err := repository.New(db, redisClient, &config)
 	if err != nil {
 		panic(err)
 	}
 
 	// Initialize service
-	service, err := service.New(repo, db, redis)
+	service, err := service.New(repo, db, &config)
 	if err != nil {
 		panic(err)
 	}
 
+	// Start background jobs
+	job.Start(context.Background(), service, &config)
+
 	// Setup routes
-	http.InitHandler(e, service)
+	http.InitHandler(e, service, &config)
 
 	// Start server
 	port := fmt.Sprintf(":%s", config.Port)
@@ -0,0 +1 @@
+-- Nothing to revert, the sequence position is not restored
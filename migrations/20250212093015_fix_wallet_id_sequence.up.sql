@@ -0,0 +1,3 @@
+-- Dummy wallets were inserted with explicit ids, move the sequence past them
+-- so wallets created through the API don't collide
+SELECT setval(pg_get_serial_sequence('wallet_table', 'id'), COALESCE((SELECT MAX(id) FROM wallet_table), 1));
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "idempotency_table" (
+	idem_key VARCHAR(512) PRIMARY KEY NOT NULL,
+	idem_status VARCHAR(32) NOT NULL,
+	idem_fingerprint VARCHAR(64) NOT NULL,
+	idem_status_code INT NOT NULL,
+	idem_body JSONB,
+	created_at TIMESTAMPTZ NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_idempotency_table_expires_at" ON "idempotency_table" (expires_at);
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS "idx_transaction_table_wallet_id" ON "transaction_table" (wallet_id);
+
+DROP INDEX IF EXISTS "idx_transaction_table_wallet_id_created_at_id";
@@ -0,0 +1,5 @@
+-- Wallet history pages by (created_at, id) newest first within a wallet
+CREATE INDEX IF NOT EXISTS "idx_transaction_table_wallet_id_created_at_id" ON "transaction_table" (wallet_id, created_at DESC, id DESC);
+
+-- Covered by the composite index above
+DROP INDEX IF EXISTS "idx_transaction_table_wallet_id";
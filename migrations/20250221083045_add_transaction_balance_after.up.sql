@@ -0,0 +1,15 @@
+ALTER TABLE "transaction_table" ADD COLUMN IF NOT EXISTS trc_balance_after NUMERIC(36, 18);
+
+-- Wallets open with a zero balance, so the running sum of every entry is the balance after it
+UPDATE "transaction_table" AS t
+SET trc_balance_after = r.balance_after
+FROM (
+	SELECT
+		id,
+		SUM(CASE WHEN trc_is_debit THEN trc_value ELSE -trc_value END)
+			OVER (PARTITION BY wallet_id ORDER BY created_at, id) AS balance_after
+	FROM "transaction_table"
+) AS r
+WHERE t.id = r.id;
+
+ALTER TABLE "transaction_table" ALTER COLUMN trc_balance_after SET NOT NULL;
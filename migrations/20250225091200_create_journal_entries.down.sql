@@ -0,0 +1,11 @@
+DELETE FROM "transaction_table"
+WHERE wallet_id IN (SELECT id FROM "wallet_table" WHERE wallet_system_code IS NOT NULL);
+
+DROP INDEX IF EXISTS "idx_transaction_table_journal_entry_id";
+ALTER TABLE "transaction_table" DROP COLUMN IF EXISTS journal_entry_id;
+
+DELETE FROM "wallet_table" WHERE wallet_system_code IS NOT NULL;
+DROP INDEX IF EXISTS "idx_wallet_table_wallet_system_code";
+ALTER TABLE "wallet_table" DROP COLUMN IF EXISTS wallet_system_code;
+
+DROP TABLE IF EXISTS "journal_entry_table";
@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS "journal_entry_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	je_type SMALLINT NOT NULL,
+	je_remarks TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+-- System wallets are the counter accounts of the ledger
+ALTER TABLE "wallet_table" ADD COLUMN IF NOT EXISTS wallet_system_code VARCHAR(32);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_wallet_table_wallet_system_code" ON "wallet_table" (wallet_system_code);
+
+INSERT INTO "wallet_table" (wallet_name, wallet_curr_balance, wallet_system_code, created_at, updated_at, deleted_at)
+VALUES
+	('System - Cash In', '0', 'CASH_IN', NOW(), NOW(), NULL),
+	('System - Cash Out', '0', 'CASH_OUT', NOW(), NOW(), NULL),
+	('System - Fees', '0', 'FEES', NOW(), NOW(), NULL),
+	('System - Suspense', '0', 'SUSPENSE', NOW(), NOW(), NULL);
+
+ALTER TABLE "transaction_table" ADD COLUMN IF NOT EXISTS journal_entry_id BIGINT;
+
+-- Every existing row becomes its own journal entry, reusing the transaction id
+INSERT INTO "journal_entry_table" (id, je_type, je_remarks, created_at)
+SELECT id, trc_type, trc_remarks, created_at
+FROM "transaction_table";
+
+SELECT setval(pg_get_serial_sequence('journal_entry_table', 'id'), COALESCE((SELECT MAX(id) FROM "journal_entry_table"), 1));
+
+UPDATE "transaction_table" SET journal_entry_id = id;
+
+-- Give each migrated row its counter leg. The two legs of an old transfer were never
+-- linked, so they are balanced against the suspense account, which nets out to zero.
+INSERT INTO "transaction_table" (journal_entry_id, wallet_id, trc_type, trc_is_debit, trc_value, trc_balance_after, trc_remarks, created_at)
+SELECT
+	t.journal_entry_id,
+	w.id,
+	t.trc_type,
+	NOT t.trc_is_debit,
+	t.trc_value,
+	0,
+	t.trc_remarks,
+	t.created_at
+FROM "transaction_table" AS t
+JOIN "wallet_table" AS w ON w.wallet_system_code = CASE t.trc_type
+	WHEN 1 THEN 'CASH_OUT'
+	WHEN 2 THEN 'CASH_IN'
+	ELSE 'SUSPENSE'
+END;
+
+-- Running and current balances of the system wallets follow from their new postings
+UPDATE "transaction_table" AS t
+SET trc_balance_after = r.balance_after
+FROM (
+	SELECT
+		t.id,
+		SUM(CASE WHEN t.trc_is_debit THEN t.trc_value ELSE -t.trc_value END)
+			OVER (PARTITION BY t.wallet_id ORDER BY t.created_at, t.id) AS balance_after
+	FROM "transaction_table" AS t
+	JOIN "wallet_table" AS w ON w.id = t.wallet_id
+	WHERE w.wallet_system_code IS NOT NULL
+) AS r
+WHERE t.id = r.id;
+
+UPDATE "wallet_table" AS w
+SET wallet_curr_balance = COALESCE((
+	SELECT SUM(CASE WHEN t.trc_is_debit THEN t.trc_value ELSE -t.trc_value END)
+	FROM "transaction_table" AS t
+	WHERE t.wallet_id = w.id
+), 0)
+WHERE w.wallet_system_code IS NOT NULL;
+
+ALTER TABLE "transaction_table" ALTER COLUMN journal_entry_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS "idx_transaction_table_journal_entry_id" ON "transaction_table" (journal_entry_id);
@@ -0,0 +1 @@
+ALTER TABLE "transaction_table" DROP COLUMN IF EXISTS trc_counterparty_wallet_id;
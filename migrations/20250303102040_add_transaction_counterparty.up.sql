@@ -0,0 +1,9 @@
+ALTER TABLE "transaction_table" ADD COLUMN IF NOT EXISTS trc_counterparty_wallet_id BIGINT;
+
+-- In a two-leg journal entry each leg's counterparty is the other leg's wallet
+UPDATE "transaction_table" AS t
+SET trc_counterparty_wallet_id = o.wallet_id
+FROM "transaction_table" AS o
+WHERE o.journal_entry_id = t.journal_entry_id
+	AND o.id <> t.id
+	AND (SELECT COUNT(*) FROM "transaction_table" AS c WHERE c.journal_entry_id = t.journal_entry_id) = 2;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "idx_journal_entry_table_je_reversal_of_id";
+
+ALTER TABLE "journal_entry_table"
+	DROP COLUMN IF EXISTS je_amount,
+	DROP COLUMN IF EXISTS je_reversed_amount,
+	DROP COLUMN IF EXISTS je_reversal_of_id;
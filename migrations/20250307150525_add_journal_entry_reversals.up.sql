@@ -0,0 +1,16 @@
+ALTER TABLE "journal_entry_table"
+	ADD COLUMN IF NOT EXISTS je_amount NUMERIC(36, 18),
+	ADD COLUMN IF NOT EXISTS je_reversed_amount NUMERIC(36, 18) NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS je_reversal_of_id BIGINT;
+
+-- Every entry so far has legs of the same value, which is the amount of the operation
+UPDATE "journal_entry_table" AS j
+SET je_amount = COALESCE((
+	SELECT MAX(t.trc_value)
+	FROM "transaction_table" AS t
+	WHERE t.journal_entry_id = j.id
+), 0);
+
+ALTER TABLE "journal_entry_table" ALTER COLUMN je_amount SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_journal_entry_table_je_reversal_of_id" ON "journal_entry_table" (je_reversal_of_id);
@@ -0,0 +1,5 @@
+ALTER TABLE "wallet_table" DROP COLUMN IF EXISTS wallet_held_balance;
+
+DROP INDEX IF EXISTS "idx_hold_table_active_expires_at";
+DROP INDEX IF EXISTS "idx_hold_table_wallet_id";
+DROP TABLE IF EXISTS "hold_table";
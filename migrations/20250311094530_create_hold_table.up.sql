@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "hold_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	wallet_id BIGINT NOT NULL,
+	hold_amount NUMERIC(36, 18) NOT NULL,
+	hold_captured_amount NUMERIC(36, 18) NOT NULL DEFAULT 0,
+	hold_status VARCHAR(16) NOT NULL,
+	hold_remarks TEXT NOT NULL DEFAULT '',
+	hold_capture_journal_entry_id BIGINT,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_hold_table_wallet_id" ON "hold_table" (wallet_id);
+
+-- The expiry job only looks at active holds
+CREATE INDEX IF NOT EXISTS "idx_hold_table_active_expires_at" ON "hold_table" (expires_at) WHERE hold_status = 'active';
+
+-- Sum of the active holds of the wallet, kept next to the balance so it is read under the same lock
+ALTER TABLE "wallet_table" ADD COLUMN IF NOT EXISTS wallet_held_balance NUMERIC(36, 18) NOT NULL DEFAULT 0;
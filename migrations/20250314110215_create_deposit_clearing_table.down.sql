@@ -0,0 +1,5 @@
+ALTER TABLE "wallet_table" DROP COLUMN IF EXISTS wallet_uncleared_balance;
+
+DROP INDEX IF EXISTS "idx_deposit_clearing_table_pending_clears_at";
+DROP INDEX IF EXISTS "idx_deposit_clearing_table_journal_entry_id";
+DROP TABLE IF EXISTS "deposit_clearing_table";
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "deposit_clearing_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	wallet_id BIGINT NOT NULL,
+	journal_entry_id BIGINT NOT NULL,
+	dc_amount NUMERIC(36, 18) NOT NULL,
+	clears_at TIMESTAMPTZ NOT NULL,
+	cleared_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_deposit_clearing_table_journal_entry_id" ON "deposit_clearing_table" (journal_entry_id);
+
+-- The release job only looks at deposits that haven't cleared
+CREATE INDEX IF NOT EXISTS "idx_deposit_clearing_table_pending_clears_at" ON "deposit_clearing_table" (clears_at) WHERE cleared_at IS NULL;
+
+-- Sum of the uncleared deposits of the wallet, read under the wallet lock like the held balance
+ALTER TABLE "wallet_table" ADD COLUMN IF NOT EXISTS wallet_uncleared_balance NUMERIC(36, 18) NOT NULL DEFAULT 0;
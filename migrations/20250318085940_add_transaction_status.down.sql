@@ -0,0 +1,5 @@
+ALTER TABLE "transaction_table"
+	DROP COLUMN IF EXISTS trc_reversed_at,
+	DROP COLUMN IF EXISTS trc_failed_at,
+	DROP COLUMN IF EXISTS trc_completed_at,
+	DROP COLUMN IF EXISTS trc_status;
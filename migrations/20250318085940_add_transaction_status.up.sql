@@ -0,0 +1,21 @@
+ALTER TABLE "transaction_table"
+	ADD COLUMN IF NOT EXISTS trc_status VARCHAR(16) NOT NULL DEFAULT 'completed',
+	ADD COLUMN IF NOT EXISTS trc_completed_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS trc_failed_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS trc_reversed_at TIMESTAMPTZ;
+
+-- Every existing row was final when it was created
+UPDATE "transaction_table" SET trc_completed_at = created_at;
+
+-- Entries reversed in full so far move on to reversed, at the time of their last reversal
+UPDATE "transaction_table" AS t
+SET trc_status = 'reversed',
+	trc_reversed_at = (
+		SELECT MAX(r.created_at)
+		FROM "journal_entry_table" AS r
+		WHERE r.je_reversal_of_id = t.journal_entry_id
+	)
+FROM "journal_entry_table" AS j
+WHERE j.id = t.journal_entry_id
+	AND j.je_reversed_amount >= j.je_amount
+	AND j.je_amount > 0;
@@ -0,0 +1,5 @@
+-- System wallets of other currencies must be emptied and removed before going down
+DROP INDEX IF EXISTS "idx_wallet_table_wallet_system_code_currency";
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_wallet_table_wallet_system_code" ON "wallet_table" (wallet_system_code);
+
+ALTER TABLE "wallet_table" DROP COLUMN IF EXISTS wallet_currency;
@@ -0,0 +1,6 @@
+-- Every wallet so far was in the default currency
+ALTER TABLE "wallet_table" ADD COLUMN IF NOT EXISTS wallet_currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+-- System wallets now exist once per currency
+DROP INDEX IF EXISTS "idx_wallet_table_wallet_system_code";
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_wallet_table_wallet_system_code_currency" ON "wallet_table" (wallet_system_code, wallet_currency) WHERE wallet_system_code IS NOT NULL;
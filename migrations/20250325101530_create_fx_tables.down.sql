@@ -0,0 +1,12 @@
+ALTER TABLE "journal_entry_table"
+	DROP COLUMN IF EXISTS je_target_amount,
+	DROP COLUMN IF EXISTS je_target_currency,
+	DROP COLUMN IF EXISTS je_source_amount,
+	DROP COLUMN IF EXISTS je_source_currency,
+	DROP COLUMN IF EXISTS je_fx_spread,
+	DROP COLUMN IF EXISTS je_fx_rate,
+	DROP COLUMN IF EXISTS je_fx_quote_id;
+
+DROP INDEX IF EXISTS "idx_fx_quote_table_wallet_id";
+DROP TABLE IF EXISTS "fx_quote_table";
+DROP TABLE IF EXISTS "exchange_rate_table";
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS "exchange_rate_table" (
+	er_base_currency VARCHAR(3) NOT NULL,
+	er_quote_currency VARCHAR(3) NOT NULL,
+	er_rate NUMERIC(36, 18) NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (er_base_currency, er_quote_currency)
+);
+
+CREATE TABLE IF NOT EXISTS "fx_quote_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	wallet_id BIGINT NOT NULL,
+	receiver_wallet_id BIGINT NOT NULL,
+	fxq_source_currency VARCHAR(3) NOT NULL,
+	fxq_target_currency VARCHAR(3) NOT NULL,
+	fxq_source_amount NUMERIC(36, 18) NOT NULL,
+	fxq_target_amount NUMERIC(36, 18) NOT NULL,
+	fxq_mid_rate NUMERIC(36, 18) NOT NULL,
+	fxq_spread NUMERIC(36, 18) NOT NULL,
+	fxq_rate NUMERIC(36, 18) NOT NULL,
+	fxq_journal_entry_id BIGINT,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_fx_quote_table_wallet_id" ON "fx_quote_table" (wallet_id);
+
+ALTER TABLE "journal_entry_table"
+	ADD COLUMN IF NOT EXISTS je_fx_quote_id BIGINT,
+	ADD COLUMN IF NOT EXISTS je_fx_rate NUMERIC(36, 18),
+	ADD COLUMN IF NOT EXISTS je_fx_spread NUMERIC(36, 18),
+	ADD COLUMN IF NOT EXISTS je_source_currency VARCHAR(3),
+	ADD COLUMN IF NOT EXISTS je_source_amount NUMERIC(36, 18),
+	ADD COLUMN IF NOT EXISTS je_target_currency VARCHAR(3),
+	ADD COLUMN IF NOT EXISTS je_target_amount NUMERIC(36, 18);
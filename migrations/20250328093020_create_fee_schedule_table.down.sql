@@ -0,0 +1,5 @@
+ALTER TABLE "journal_entry_table" DROP COLUMN IF EXISTS je_fee_amount;
+ALTER TABLE "transaction_table" DROP COLUMN IF EXISTS trc_is_fee;
+
+DROP INDEX IF EXISTS "idx_fee_schedule_table_active";
+DROP TABLE IF EXISTS "fee_schedule_table";
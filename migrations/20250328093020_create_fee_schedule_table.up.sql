@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS "fee_schedule_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	fs_transaction_type SMALLINT NOT NULL,
+	fs_currency VARCHAR(3) NOT NULL,
+	fs_kind VARCHAR(16) NOT NULL,
+	fs_flat_amount NUMERIC(36, 18) NOT NULL DEFAULT 0,
+	fs_percentage NUMERIC(36, 18) NOT NULL DEFAULT 0,
+	fs_min_fee NUMERIC(36, 18),
+	fs_max_fee NUMERIC(36, 18),
+	fs_tiers JSONB,
+	fs_is_active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- One schedule prices an operation, older ones are kept inactive for reference
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_fee_schedule_table_active" ON "fee_schedule_table" (fs_transaction_type, fs_currency) WHERE fs_is_active;
+
+-- Fee postings are kept apart from the operation they are charged on
+ALTER TABLE "transaction_table" ADD COLUMN IF NOT EXISTS trc_is_fee BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE "journal_entry_table" ADD COLUMN IF NOT EXISTS je_fee_amount NUMERIC(36, 18) NOT NULL DEFAULT 0;
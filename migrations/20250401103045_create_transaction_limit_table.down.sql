@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_transaction_limit_table_tier";
+DROP INDEX IF EXISTS "idx_transaction_limit_table_wallet";
+DROP TABLE IF EXISTS "transaction_limit_table";
+
+ALTER TABLE "wallet_table" DROP COLUMN IF EXISTS wallet_tier;
@@ -0,0 +1,17 @@
+ALTER TABLE "wallet_table" ADD COLUMN IF NOT EXISTS wallet_tier VARCHAR(32) NOT NULL DEFAULT 'standard';
+
+CREATE TABLE IF NOT EXISTS "transaction_limit_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	wallet_id BIGINT,
+	lim_tier VARCHAR(32),
+	lim_transaction_type SMALLINT NOT NULL,
+	lim_per_transaction NUMERIC(36, 18),
+	lim_daily_amount NUMERIC(36, 18),
+	lim_monthly_amount NUMERIC(36, 18),
+	lim_daily_count BIGINT,
+	lim_monthly_count BIGINT,
+	CHECK ((wallet_id IS NULL) <> (lim_tier IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_transaction_limit_table_wallet" ON "transaction_limit_table" (wallet_id, lim_transaction_type) WHERE wallet_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_transaction_limit_table_tier" ON "transaction_limit_table" (lim_tier, lim_transaction_type) WHERE lim_tier IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "principal_wallet_table";
+DROP INDEX IF EXISTS "idx_api_key_table_key_hash";
+DROP TABLE IF EXISTS "api_key_table";
+DROP TABLE IF EXISTS "principal_table";
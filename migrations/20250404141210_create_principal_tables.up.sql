@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS "principal_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	prc_name VARCHAR(255) NOT NULL,
+	prc_is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "api_key_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	principal_id BIGINT NOT NULL,
+	ak_key_hash VARCHAR(64) NOT NULL,
+	revoked_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_key_table_key_hash" ON "api_key_table" (ak_key_hash);
+
+CREATE TABLE IF NOT EXISTS "principal_wallet_table" (
+	principal_id BIGINT NOT NULL,
+	wallet_id BIGINT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (principal_id, wallet_id)
+);
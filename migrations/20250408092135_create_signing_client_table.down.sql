@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_signing_client_table_client_id";
+DROP TABLE IF EXISTS "signing_client_table";
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "signing_client_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	principal_id BIGINT NOT NULL,
+	sc_client_id VARCHAR(64) NOT NULL,
+	sc_secret VARCHAR(255) NOT NULL,
+	revoked_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_signing_client_table_client_id" ON "signing_client_table" (sc_client_id);
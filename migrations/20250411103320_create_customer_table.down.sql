@@ -0,0 +1,7 @@
+ALTER TABLE "principal_table" DROP COLUMN IF EXISTS customer_id;
+
+DROP INDEX IF EXISTS "idx_wallet_table_customer_id";
+ALTER TABLE "wallet_table" DROP COLUMN IF EXISTS customer_id;
+
+DROP INDEX IF EXISTS "idx_customer_table_email";
+DROP TABLE IF EXISTS "customer_table";
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "customer_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	cst_name VARCHAR(255) NOT NULL,
+	cst_email VARCHAR(255) NOT NULL,
+	cst_phone VARCHAR(32),
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	deleted_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_customer_table_email" ON "customer_table" (LOWER(cst_email)) WHERE deleted_at IS NULL;
+
+ALTER TABLE "wallet_table" ADD COLUMN IF NOT EXISTS customer_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS "idx_wallet_table_customer_id" ON "wallet_table" (customer_id);
+
+ALTER TABLE "principal_table" ADD COLUMN IF NOT EXISTS customer_id BIGINT;
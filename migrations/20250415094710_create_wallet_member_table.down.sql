@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS "idx_hold_table_active_initiator_customer_id";
+
+ALTER TABLE "hold_table"
+	DROP COLUMN IF EXISTS hold_initiator_customer_id,
+	DROP COLUMN IF EXISTS hold_initiator_principal_id;
+
+DROP INDEX IF EXISTS "idx_transaction_table_initiator_customer_id";
+
+ALTER TABLE "transaction_table"
+	DROP COLUMN IF EXISTS trc_initiator_customer_id,
+	DROP COLUMN IF EXISTS trc_initiator_principal_id;
+
+DROP INDEX IF EXISTS "idx_wallet_member_table_customer_id";
+DROP TABLE IF EXISTS "wallet_member_table";
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS "wallet_member_table" (
+	wallet_id BIGINT NOT NULL,
+	customer_id BIGINT NOT NULL,
+	wm_role VARCHAR(16) NOT NULL,
+	wm_spend_limit NUMERIC(36, 18),
+	created_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (wallet_id, customer_id)
+);
+
+CREATE INDEX IF NOT EXISTS "idx_wallet_member_table_customer_id" ON "wallet_member_table" (customer_id);
+
+-- The customer owning a wallet is its first owner
+INSERT INTO "wallet_member_table" (wallet_id, customer_id, wm_role, created_at, updated_at)
+SELECT id, customer_id, 'owner', NOW(), NOW()
+FROM "wallet_table"
+WHERE customer_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE "transaction_table"
+	ADD COLUMN IF NOT EXISTS trc_initiator_principal_id BIGINT,
+	ADD COLUMN IF NOT EXISTS trc_initiator_customer_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS "idx_transaction_table_initiator_customer_id" ON "transaction_table" (wallet_id, trc_initiator_customer_id, created_at);
+
+-- The open holds of a spender count against its daily spend limit
+ALTER TABLE "hold_table"
+	ADD COLUMN IF NOT EXISTS hold_initiator_principal_id BIGINT,
+	ADD COLUMN IF NOT EXISTS hold_initiator_customer_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS "idx_hold_table_active_initiator_customer_id" ON "hold_table" (wallet_id, hold_initiator_customer_id) WHERE hold_status = 'active';
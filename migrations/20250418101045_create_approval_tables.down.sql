@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_approval_audit_table_request_id";
+DROP TABLE IF EXISTS "approval_audit_table";
+DROP INDEX IF EXISTS "idx_approval_request_table_status_expires_at";
+DROP TABLE IF EXISTS "approval_request_table";
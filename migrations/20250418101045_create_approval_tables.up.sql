@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS "approval_request_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	apr_operation VARCHAR(32) NOT NULL,
+	wallet_id BIGINT NOT NULL,
+	apr_amount NUMERIC(36, 18) NOT NULL,
+	apr_payload JSONB NOT NULL,
+	apr_status VARCHAR(16) NOT NULL,
+	apr_maker_principal_id BIGINT NOT NULL,
+	apr_maker_customer_id BIGINT,
+	apr_checker_principal_id BIGINT,
+	apr_decision_reason VARCHAR(255),
+	apr_transaction_id BIGINT,
+	expires_at TIMESTAMPTZ NOT NULL,
+	decided_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_approval_request_table_status_expires_at" ON "approval_request_table" (apr_status, expires_at);
+
+CREATE TABLE IF NOT EXISTS "approval_audit_table" (
+	id BIGSERIAL PRIMARY KEY NOT NULL,
+	approval_request_id BIGINT NOT NULL,
+	aud_action VARCHAR(16) NOT NULL,
+	aud_principal_id BIGINT,
+	aud_remarks VARCHAR(255) NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_approval_audit_table_request_id" ON "approval_audit_table" (approval_request_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_transaction_limit_table_tier_currency";
+ALTER TABLE "transaction_limit_table" DROP CONSTRAINT IF EXISTS "chk_transaction_limit_table_tier_currency";
+ALTER TABLE "transaction_limit_table" DROP COLUMN IF EXISTS lim_currency;
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_transaction_limit_table_tier" ON "transaction_limit_table" (lim_tier, lim_transaction_type) WHERE lim_tier IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Tier limits are amounts, so they only make sense in one currency. A wallet's own limit
+-- stays in the wallet currency. Every tier limit so far was set in the default currency.
+ALTER TABLE "transaction_limit_table" ADD COLUMN IF NOT EXISTS lim_currency VARCHAR(3);
+UPDATE "transaction_limit_table" SET lim_currency = 'USD' WHERE lim_tier IS NOT NULL AND lim_currency IS NULL;
+ALTER TABLE "transaction_limit_table" ADD CONSTRAINT "chk_transaction_limit_table_tier_currency" CHECK (lim_tier IS NULL OR lim_currency IS NOT NULL);
+
+DROP INDEX IF EXISTS "idx_transaction_limit_table_tier";
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_transaction_limit_table_tier_currency" ON "transaction_limit_table" (lim_tier, lim_currency, lim_transaction_type) WHERE lim_tier IS NOT NULL;
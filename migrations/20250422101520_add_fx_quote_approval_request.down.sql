@@ -0,0 +1 @@
+ALTER TABLE "fx_quote_table" DROP COLUMN IF EXISTS fxq_approval_request_id;
@@ -0,0 +1 @@
+ALTER TABLE "fx_quote_table" ADD COLUMN IF NOT EXISTS fxq_approval_request_id BIGINT;
@@ -0,0 +1,25 @@
+DROP VIEW IF EXISTS "system_wallet_balance_view";
+
+-- Restores the stored balances of the system wallets from their postings
+UPDATE "wallet_table" AS w
+SET wallet_curr_balance = r.balance
+FROM (
+	SELECT wallet_id, SUM(CASE WHEN trc_is_debit THEN trc_value ELSE -trc_value END) AS balance
+	FROM "transaction_table"
+	GROUP BY wallet_id
+) AS r
+WHERE w.id = r.wallet_id AND w.wallet_system_code IS NOT NULL;
+
+UPDATE "transaction_table" AS t
+SET trc_balance_after = r.balance_after
+FROM (
+	SELECT
+		id,
+		SUM(CASE WHEN trc_is_debit THEN trc_value ELSE -trc_value END)
+			OVER (PARTITION BY wallet_id ORDER BY created_at, id) AS balance_after
+	FROM "transaction_table"
+	WHERE trc_balance_after IS NULL
+) AS r
+WHERE t.id = r.id;
+
+ALTER TABLE "transaction_table" ALTER COLUMN trc_balance_after SET NOT NULL;
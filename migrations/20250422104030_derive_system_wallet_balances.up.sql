@@ -0,0 +1,17 @@
+-- System wallets are only appended to, so they no longer serialize every posting of their
+-- currency on one row. Their postings carry no balance and their balance is the sum of them.
+ALTER TABLE "transaction_table" ALTER COLUMN trc_balance_after DROP NOT NULL;
+
+UPDATE "transaction_table" SET trc_balance_after = NULL
+WHERE wallet_id IN (SELECT id FROM "wallet_table" WHERE wallet_system_code IS NOT NULL);
+
+CREATE OR REPLACE VIEW "system_wallet_balance_view" AS
+SELECT
+	w.id AS wallet_id,
+	w.wallet_system_code,
+	w.wallet_currency,
+	COALESCE(SUM(CASE WHEN t.trc_is_debit THEN t.trc_value ELSE -t.trc_value END), 0) AS balance
+FROM "wallet_table" AS w
+LEFT JOIN "transaction_table" AS t ON t.wallet_id = w.id
+WHERE w.wallet_system_code IS NOT NULL
+GROUP BY w.id, w.wallet_system_code, w.wallet_currency;
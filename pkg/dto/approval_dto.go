@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+)
+
+// AdjustmentRequest corrects a wallet balance by hand, a positive Amount credits the wallet
+// and a negative one debits it. It is only posted once a second admin approves it.
+type AdjustmentRequest struct {
+	WalletID int64           `json:"wallet_id"`
+	Amount   decimal.Decimal `json:"amount"`
+	Reason   string          `json:"reason"`
+}
+
+const (
+	DefaultApprovalListLimit = 20
+	MaxApprovalListLimit     = 100
+)
+
+// ApprovalListRequest is bound from the query string, Status defaults to pending
+type ApprovalListRequest struct {
+	Status string `query:"status"`
+	Limit  int    `query:"limit"`
+}
+
+type RejectApprovalRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ApprovalResponse struct {
+	ApprovalID     int64           `json:"approval_id"`
+	Operation      string          `json:"operation"`
+	WalletID       int64           `json:"wallet_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	Request        json.RawMessage `json:"request"`
+	Status         string          `json:"status"`
+	MakerID        int64           `json:"maker_principal_id"`
+	CheckerID      *int64          `json:"checker_principal_id,omitempty"`
+	DecisionReason *string         `json:"decision_reason,omitempty"`
+	TransactionID  *int64          `json:"transaction_id,omitempty"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+	DecidedAt      *time.Time      `json:"decided_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func NewApprovalResponse(approval model.ApprovalRequest) ApprovalResponse {
+	return ApprovalResponse{
+		ApprovalID:     approval.ID,
+		Operation:      approval.Operation,
+		WalletID:       approval.WalletID,
+		Amount:         approval.Amount,
+		Request:        json.RawMessage(approval.Payload),
+		Status:         approval.Status,
+		MakerID:        approval.MakerID,
+		CheckerID:      approval.CheckerID,
+		DecisionReason: approval.DecisionReason,
+		TransactionID:  approval.TransactionID,
+		ExpiresAt:      approval.ExpiresAt,
+		DecidedAt:      approval.DecidedAt,
+		CreatedAt:      approval.CreatedAt,
+	}
+}
+
+type ApprovalListResponse struct {
+	Data []ApprovalResponse `json:"data"`
+}
+
+func NewApprovalListResponse(approvals []model.ApprovalRequest) ApprovalListResponse {
+	resp := ApprovalListResponse{Data: make([]ApprovalResponse, 0, len(approvals))}
+	for _, approval := range approvals {
+		resp.Data = append(resp.Data, NewApprovalResponse(approval))
+	}
+	return resp
+}
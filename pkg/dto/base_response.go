@@ -1,5 +1,25 @@
 package dto
 
 type BaseError struct {
-	Message string `json:"error_message"`
+	Code    string                 `json:"error_code"`
+	Message string                 `json:"error_message"`
+	Details map[string]interface{} `json:"error_details,omitempty"`
+}
+
+// ProblemDetails is an RFC 7807 application/problem+json body
+type ProblemDetails struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     string              `json:"code"`
+	Errors   []ProblemFieldError `json:"errors,omitempty"`
+	// Details is an extension member with the error's machine readable context
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+type ProblemFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+)
+
+type CreateCustomerRequest struct {
+	Name  string  `json:"name"`
+	Email string  `json:"email"`
+	Phone *string `json:"phone,omitempty"`
+}
+
+// UpdateCustomerRequest only changes the fields that are set
+type UpdateCustomerRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Email *string `json:"email,omitempty"`
+	Phone *string `json:"phone,omitempty"`
+}
+
+type CustomerResponse struct {
+	CustomerID int64     `json:"customer_id"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	Phone      *string   `json:"phone,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewCustomerResponse(customer model.Customer) CustomerResponse {
+	return CustomerResponse{
+		CustomerID: customer.ID,
+		Name:       customer.Name,
+		Email:      customer.Email,
+		Phone:      customer.Phone,
+		CreatedAt:  customer.CreatedAt,
+		UpdatedAt:  customer.UpdatedAt,
+	}
+}
+
+// CustomerWalletResponse is a wallet the customer owns or shares, Role is the customer's role on it
+type CustomerWalletResponse struct {
+	WalletID         int64           `json:"wallet_id"`
+	Name             string          `json:"name"`
+	Currency         string          `json:"currency"`
+	Role             string          `json:"role"`
+	LedgerBalance    decimal.Decimal `json:"ledger_balance"`
+	HeldBalance      decimal.Decimal `json:"held_balance"`
+	UnclearedBalance decimal.Decimal `json:"uncleared_balance"`
+	AvailableBalance decimal.Decimal `json:"available_balance"`
+}
+
+type CustomerWalletsResponse struct {
+	CustomerID int64                    `json:"customer_id"`
+	Wallets    []CustomerWalletResponse `json:"wallets"`
+}
+
+func NewCustomerWalletsResponse(customerID int64, wallets []model.Wallet, roles map[int64]string) CustomerWalletsResponse {
+	resp := CustomerWalletsResponse{
+		CustomerID: customerID,
+		Wallets:    make([]CustomerWalletResponse, 0, len(wallets)),
+	}
+	for _, wallet := range wallets {
+		resp.Wallets = append(resp.Wallets, CustomerWalletResponse{
+			WalletID:         wallet.ID,
+			Name:             wallet.Name,
+			Currency:         wallet.Currency,
+			Role:             roles[wallet.ID],
+			LedgerBalance:    wallet.CurrentBalance,
+			HeldBalance:      wallet.HeldBalance,
+			UnclearedBalance: wallet.UnclearedBalance,
+			AvailableBalance: wallet.AvailableBalance(),
+		})
+	}
+	return resp
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// FeePreviewRequest is bound from the query string
+type FeePreviewRequest struct {
+	TransactionType int16           `query:"transaction_type"`
+	Amount          decimal.Decimal `query:"amount"`
+}
+
+type FeePreviewResponse struct {
+	TransactionType int16           `json:"transaction_type"`
+	Currency        string          `json:"currency"`
+	Amount          decimal.Decimal `json:"amount"`
+	Fee             decimal.Decimal `json:"fee"`
+	Total           decimal.Decimal `json:"total"`
+}
@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+)
+
+// FXQuoteRequest prices sending Amount, in the sender's currency, to the receiver wallet
+type FXQuoteRequest struct {
+	ReceiverWalletID int64           `json:"receiver_wallet_id"`
+	Amount           decimal.Decimal `json:"amount"`
+}
+
+type FXQuoteResponse struct {
+	QuoteID          int64           `json:"quote_id"`
+	WalletID         int64           `json:"wallet_id"`
+	ReceiverWalletID int64           `json:"receiver_wallet_id"`
+	SourceCurrency   string          `json:"source_currency"`
+	SourceAmount     decimal.Decimal `json:"source_amount"`
+	TargetCurrency   string          `json:"target_currency"`
+	TargetAmount     decimal.Decimal `json:"target_amount"`
+	MidRate          decimal.Decimal `json:"mid_rate"`
+	Spread           decimal.Decimal `json:"spread"`
+	Rate             decimal.Decimal `json:"rate"`
+	ExpiresAt        time.Time       `json:"expires_at"`
+}
+
+// FXDetailResponse is the conversion recorded on a cross-currency journal entry
+type FXDetailResponse struct {
+	QuoteID        int64           `json:"quote_id"`
+	Rate           decimal.Decimal `json:"rate"`
+	Spread         decimal.Decimal `json:"spread"`
+	SourceCurrency string          `json:"source_currency"`
+	SourceAmount   decimal.Decimal `json:"source_amount"`
+	TargetCurrency string          `json:"target_currency"`
+	TargetAmount   decimal.Decimal `json:"target_amount"`
+}
+
+func NewFXQuoteResponse(quote model.FXQuote) FXQuoteResponse {
+	return FXQuoteResponse{
+		QuoteID:          quote.ID,
+		WalletID:         quote.WalletID,
+		ReceiverWalletID: quote.ReceiverWalletID,
+		SourceCurrency:   quote.SourceCurrency,
+		SourceAmount:     quote.SourceAmount,
+		TargetCurrency:   quote.TargetCurrency,
+		TargetAmount:     quote.TargetAmount,
+		MidRate:          quote.MidRate,
+		Spread:           quote.Spread,
+		Rate:             quote.Rate,
+		ExpiresAt:        quote.ExpiresAt,
+	}
+}
+
+// NewFXDetailResponse returns nil for an entry without a conversion
+func NewFXDetailResponse(entry model.JournalEntry) *FXDetailResponse {
+	if entry.FXQuoteID == nil || entry.FXRate == nil || entry.FXSpread == nil ||
+		entry.SourceCurrency == nil || entry.SourceAmount == nil ||
+		entry.TargetCurrency == nil || entry.TargetAmount == nil {
+		return nil
+	}
+	return &FXDetailResponse{
+		QuoteID:        *entry.FXQuoteID,
+		Rate:           *entry.FXRate,
+		Spread:         *entry.FXSpread,
+		SourceCurrency: *entry.SourceCurrency,
+		SourceAmount:   *entry.SourceAmount,
+		TargetCurrency: *entry.TargetCurrency,
+		TargetAmount:   *entry.TargetAmount,
+	}
+}
@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+)
+
+// CreateHoldRequest expires after the configured default when ExpiresInSeconds is not set,
+// it may not be set beyond the configured maximum
+type CreateHoldRequest struct {
+	Amount           decimal.Decimal `json:"amount"`
+	ExpiresInSeconds int64           `json:"expires_in_seconds,omitempty"`
+	Remarks          string          `json:"remarks"`
+}
+
+// CaptureHoldRequest captures the whole hold when Amount is not set, the rest is released
+type CaptureHoldRequest struct {
+	Amount *decimal.Decimal `json:"amount,omitempty"`
+}
+
+type HoldResponse struct {
+	HoldID                int64           `json:"hold_id"`
+	WalletID              int64           `json:"wallet_id"`
+	Amount                decimal.Decimal `json:"amount"`
+	CapturedAmount        decimal.Decimal `json:"captured_amount"`
+	Status                string          `json:"status"`
+	Remarks               string          `json:"remarks"`
+	CaptureJournalEntryID *int64          `json:"capture_journal_entry_id,omitempty"`
+	ExpiresAt             time.Time       `json:"expires_at"`
+	CreatedAt             time.Time       `json:"created_at"`
+	UpdatedAt             time.Time       `json:"updated_at"`
+}
+
+type CaptureHoldResponse struct {
+	TransactionID int64        `json:"transaction_id"`
+	Hold          HoldResponse `json:"hold"`
+}
+
+func NewHoldResponse(hold model.Hold) HoldResponse {
+	return HoldResponse{
+		HoldID:                hold.ID,
+		WalletID:              hold.WalletID,
+		Amount:                hold.Amount,
+		CapturedAmount:        hold.CapturedAmount,
+		Status:                hold.Status,
+		Remarks:               hold.Remarks,
+		CaptureJournalEntryID: hold.CaptureJournalEntryID,
+		ExpiresAt:             hold.ExpiresAt,
+		CreatedAt:             hold.CreatedAt,
+		UpdatedAt:             hold.UpdatedAt,
+	}
+}
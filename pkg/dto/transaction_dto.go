@@ -11,37 +11,177 @@ type AmountRequest struct {
 	Amount decimal.Decimal `json:"amount"`
 }
 
+// TransferRequest converts with the quote when QuoteID is set, Amount may then be left out
 type TransferRequest struct {
 	ReceiverWalletID int64           `json:"receiver_wallet_id"`
 	Amount           decimal.Decimal `json:"amount"`
+	QuoteID          *int64          `json:"quote_id,omitempty"`
 }
 
+// ReverseRequest reverses the whole remaining amount when Amount is not set
+type ReverseRequest struct {
+	Amount *decimal.Decimal `json:"amount,omitempty"`
+	Reason string           `json:"reason"`
+}
+
+// ReversalResponse of a reversal waiting for approval only has TransactionID, ApprovalID
+// and ApprovalExpiresAt
+type ReversalResponse struct {
+	ReversalID     int64           `json:"reversal_id"`
+	TransactionID  int64           `json:"transaction_id"`
+	JournalEntryID int64           `json:"journal_entry_id"`
+	Amount         decimal.Decimal `json:"amount"`
+	TotalReversed  decimal.Decimal `json:"total_reversed"`
+	Remaining      decimal.Decimal `json:"remaining"`
+
+	ApprovalID        *int64     `json:"approval_id,omitempty"`
+	ApprovalExpiresAt *time.Time `json:"approval_expires_at,omitempty"`
+}
+
+// TransactionResponse carries ClearsAt for a deposit that is not available until then and
+// Fee for an operation that was charged one. A transfer waiting for approval has no
+// TransactionID yet, only ApprovalID and ApprovalExpiresAt.
 type TransactionResponse struct {
-	TransactionID int64 `json:"transaction_id"`
+	TransactionID int64            `json:"transaction_id"`
+	TransferID    int64            `json:"transfer_id,omitempty"`
+	Fee           *decimal.Decimal `json:"fee,omitempty"`
+	ClearsAt      *time.Time       `json:"clears_at,omitempty"`
+
+	ApprovalID        *int64     `json:"approval_id,omitempty"`
+	ApprovalExpiresAt *time.Time `json:"approval_expires_at,omitempty"`
+}
+
+// FeeAmount leaves a zero fee out of the response
+func FeeAmount(fee decimal.Decimal) *decimal.Decimal {
+	if !fee.IsPositive() {
+		return nil
+	}
+	return &fee
 }
 
 type TransactionDetailResponse struct {
-	TransactionID int64           `json:"transaction_id"`
-	WalletID      int64           `json:"wallet_id"`
-	Type          int16           `json:"type"`
-	IsDebit       bool            `json:"is_debit"`
-	Value         decimal.Decimal `json:"value"`
-	Remarks       string          `json:"remarks"`
-	CreatedAt     time.Time       `json:"created_at"`
+	TransactionID        int64            `json:"transaction_id"`
+	JournalEntryID       int64            `json:"journal_entry_id"`
+	WalletID             int64            `json:"wallet_id"`
+	CounterpartyWalletID *int64           `json:"counterparty_wallet_id,omitempty"`
+	Type                 int16            `json:"type"`
+	IsDebit              bool             `json:"is_debit"`
+	Value                decimal.Decimal  `json:"value"`
+	BalanceAfter         *decimal.Decimal `json:"balance_after,omitempty"`
+	Remarks              string           `json:"remarks"`
+	IsFee                bool             `json:"is_fee"`
+	Status               string           `json:"status"`
+	InitiatorID          *int64           `json:"initiator_principal_id,omitempty"`
+	InitiatorCustomerID  *int64           `json:"initiator_customer_id,omitempty"`
+	CompletedAt          *time.Time       `json:"completed_at,omitempty"`
+	FailedAt             *time.Time       `json:"failed_at,omitempty"`
+	ReversedAt           *time.Time       `json:"reversed_at,omitempty"`
+	CreatedAt            time.Time        `json:"created_at"`
+}
+
+const (
+	DefaultHistoryLimit = 20
+	MaxHistoryLimit     = 100
+)
+
+// WalletHistoryRequest is bound from the query string, from is inclusive and to is exclusive
+type WalletHistoryRequest struct {
+	Cursor    string           `query:"cursor"`
+	Limit     int              `query:"limit"`
+	Type      *int16           `query:"type"`
+	IsDebit   *bool            `query:"is_debit"`
+	MinAmount *decimal.Decimal `query:"min_amount"`
+	MaxAmount *decimal.Decimal `query:"max_amount"`
+	From      *time.Time       `query:"from"`
+	To        *time.Time       `query:"to"`
+}
+
+type TransactionHistoryResponse struct {
+	Data       []TransactionDetailResponse `json:"data"`
+	NextCursor string                      `json:"next_cursor,omitempty"`
 }
 
 func NewTransactionListResponse(transactions []model.Transaction) []TransactionDetailResponse {
-	var resp []TransactionDetailResponse
+	resp := make([]TransactionDetailResponse, 0, len(transactions))
 	for _, transaction := range transactions {
-		resp = append(resp, TransactionDetailResponse{
-			TransactionID: transaction.ID,
-			WalletID:      transaction.WalletID,
-			Type:          transaction.Type,
-			IsDebit:       transaction.IsDebit,
-			Value:         transaction.Value,
-			Remarks:       transaction.Remarks,
-			CreatedAt:     transaction.CreatedAt,
-		})
+		resp = append(resp, NewTransactionDetailResponse(transaction))
+	}
+	return resp
+}
+
+func NewTransactionDetailResponse(transaction model.Transaction) TransactionDetailResponse {
+	return TransactionDetailResponse{
+		TransactionID:        transaction.ID,
+		JournalEntryID:       transaction.JournalEntryID,
+		WalletID:             transaction.WalletID,
+		CounterpartyWalletID: transaction.CounterpartyID,
+		Type:                 transaction.Type,
+		IsDebit:              transaction.IsDebit,
+		Value:                transaction.Value,
+		BalanceAfter:         transaction.BalanceAfter,
+		Remarks:              transaction.Remarks,
+		IsFee:                transaction.IsFee,
+		Status:               transaction.Status,
+		InitiatorID:          transaction.InitiatorID,
+		InitiatorCustomerID:  transaction.InitiatorCustomerID,
+		CompletedAt:          transaction.CompletedAt,
+		FailedAt:             transaction.FailedAt,
+		ReversedAt:           transaction.ReversedAt,
+		CreatedAt:            transaction.CreatedAt,
+	}
+}
+
+type TransferDetailResponse struct {
+	TransferID       int64                       `json:"transfer_id"`
+	Status           string                      `json:"status"`
+	SenderWalletID   int64                       `json:"sender_wallet_id"`
+	ReceiverWalletID int64                       `json:"receiver_wallet_id"`
+	Amount           decimal.Decimal             `json:"amount"`
+	ReceivedAmount   decimal.Decimal             `json:"received_amount"`
+	Fee              decimal.Decimal             `json:"fee"`
+	FX               *FXDetailResponse           `json:"fx,omitempty"`
+	CreatedAt        time.Time                   `json:"created_at"`
+	Legs             []TransactionDetailResponse `json:"legs"`
+}
+
+func NewTransferDetailResponse(entry model.JournalEntry, legs []model.Transaction) TransferDetailResponse {
+	resp := TransferDetailResponse{
+		TransferID: entry.ID,
+		FX:         NewFXDetailResponse(entry),
+		Fee:        entry.FeeAmount,
+		CreatedAt:  entry.CreatedAt,
+		Legs:       NewTransactionListResponse(legs),
+	}
+
+	// Fee legs are listed but don't tell who sent or received
+	transferLegs := make([]model.Transaction, 0, len(legs))
+	for _, leg := range legs {
+		if !leg.IsFee {
+			transferLegs = append(transferLegs, leg)
+		}
+	}
+
+	// A conversion also posts to the FX wallets, the customer legs are the first and the last
+	if resp.FX != nil && len(transferLegs) > 0 {
+		sender, receiver := transferLegs[0], transferLegs[len(transferLegs)-1]
+		resp.Status = sender.Status
+		resp.SenderWalletID = sender.WalletID
+		resp.Amount = sender.Value
+		resp.ReceiverWalletID = receiver.WalletID
+		resp.ReceivedAmount = receiver.Value
+		return resp
+	}
+
+	// Legs move status together, any of them tells the status of the transfer
+	for _, leg := range transferLegs {
+		resp.Status = leg.Status
+		if leg.IsDebit {
+			resp.ReceiverWalletID = leg.WalletID
+			resp.ReceivedAmount = leg.Value
+		} else {
+			resp.SenderWalletID = leg.WalletID
+			resp.Amount = leg.Value
+		}
 	}
 	return resp
 }
@@ -0,0 +1,229 @@
+package dto
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/constant"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/apperror"
+	"github.com/shopspring/decimal"
+)
+
+// validationErrors collects field errors, Err returns nil when nothing was added
+type validationErrors []apperror.FieldError
+
+func (v *validationErrors) add(field string, message string) {
+	*v = append(*v, apperror.FieldError{Field: field, Message: message})
+}
+
+func (v validationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return apperror.Validation(v...)
+}
+
+func (v *validationErrors) requirePositive(field string, value decimal.Decimal) {
+	if value.LessThanOrEqual(decimal.Zero) {
+		v.add(field, "must be greater than 0")
+	}
+}
+
+func (v *validationErrors) requireText(field string, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (r AmountRequest) Validate() error {
+	var errs validationErrors
+	errs.requirePositive("amount", r.Amount)
+	return errs.Err()
+}
+
+func (r TransferRequest) Validate() error {
+	var errs validationErrors
+	if r.ReceiverWalletID <= 0 {
+		errs.add("receiver_wallet_id", "is required")
+	}
+	if r.QuoteID == nil || !r.Amount.IsZero() {
+		errs.requirePositive("amount", r.Amount)
+	}
+	if r.QuoteID != nil && *r.QuoteID <= 0 {
+		errs.add("quote_id", "must be a positive id")
+	}
+	return errs.Err()
+}
+
+func (r FXQuoteRequest) Validate() error {
+	var errs validationErrors
+	if r.ReceiverWalletID <= 0 {
+		errs.add("receiver_wallet_id", "is required")
+	}
+	errs.requirePositive("amount", r.Amount)
+	return errs.Err()
+}
+
+func (r ReverseRequest) Validate() error {
+	var errs validationErrors
+	if r.Amount != nil {
+		errs.requirePositive("amount", *r.Amount)
+	}
+	if len(r.Reason) > 255 {
+		errs.add("reason", "must be at most 255 characters")
+	}
+	return errs.Err()
+}
+
+func (r WalletHistoryRequest) Validate() error {
+	var errs validationErrors
+	if r.Limit < 0 || r.Limit > MaxHistoryLimit {
+		errs.add("limit", "must be between 1 and 100")
+	}
+	if r.Type != nil && !constant.IsTransactionType(*r.Type) {
+		errs.add("type", "is not a known transaction type")
+	}
+	if r.MinAmount != nil && r.MaxAmount != nil && r.MinAmount.GreaterThan(*r.MaxAmount) {
+		errs.add("min_amount", "must not be greater than max_amount")
+	}
+	if r.From != nil && r.To != nil && !r.From.Before(*r.To) {
+		errs.add("from", "must be before to")
+	}
+	return errs.Err()
+}
+
+func (r CreateWalletRequest) Validate() error {
+	var errs validationErrors
+	errs.requireText("name", r.Name)
+	if r.Currency != "" {
+		if _, ok := constant.CurrencyMinorUnits(r.Currency); !ok {
+			errs.add("currency", "is not a supported ISO 4217 currency code")
+		}
+	}
+	return errs.Err()
+}
+
+func (r RenameWalletRequest) Validate() error {
+	var errs validationErrors
+	errs.requireText("name", r.Name)
+	return errs.Err()
+}
+
+func (v *validationErrors) requireEmail(field string, value string) {
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		v.add(field, "is not a valid email address")
+	}
+}
+
+func (v *validationErrors) maxLength(field string, value string, max int) {
+	if len(value) > max {
+		v.add(field, fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+func (r CreateCustomerRequest) Validate() error {
+	var errs validationErrors
+	errs.requireText("name", r.Name)
+	errs.maxLength("name", r.Name, 255)
+	errs.requireEmail("email", r.Email)
+	if r.Phone != nil {
+		errs.maxLength("phone", *r.Phone, 32)
+	}
+	return errs.Err()
+}
+
+func (r UpdateCustomerRequest) Validate() error {
+	var errs validationErrors
+	if r.Name != nil {
+		errs.requireText("name", *r.Name)
+		errs.maxLength("name", *r.Name, 255)
+	}
+	if r.Email != nil {
+		errs.requireEmail("email", *r.Email)
+	}
+	if r.Phone != nil {
+		errs.maxLength("phone", *r.Phone, 32)
+	}
+	return errs.Err()
+}
+
+func (r SaveWalletMemberRequest) Validate() error {
+	var errs validationErrors
+	if !constant.IsWalletRole(r.Role) {
+		errs.add("role", "must be owner, spender or viewer")
+	}
+	if r.SpendLimit != nil {
+		if r.Role != constant.WalletRoleSpender {
+			errs.add("spend_limit", "is only accepted for spenders")
+		}
+		errs.requirePositive("spend_limit", *r.SpendLimit)
+	}
+	return errs.Err()
+}
+
+func (r AdjustmentRequest) Validate() error {
+	var errs validationErrors
+	if r.WalletID <= 0 {
+		errs.add("wallet_id", "is required")
+	}
+	if r.Amount.IsZero() {
+		errs.add("amount", "must not be 0")
+	}
+	errs.requireText("reason", r.Reason)
+	errs.maxLength("reason", r.Reason, 255)
+	return errs.Err()
+}
+
+func (r ApprovalListRequest) Validate() error {
+	var errs validationErrors
+	if r.Status != "" && r.Status != constant.ApprovalStatusPending && r.Status != constant.ApprovalStatusApproved &&
+		r.Status != constant.ApprovalStatusRejected && r.Status != constant.ApprovalStatusExpired {
+		errs.add("status", "must be pending, approved, rejected or expired")
+	}
+	if r.Limit < 0 || r.Limit > MaxApprovalListLimit {
+		errs.add("limit", "must be between 1 and 100")
+	}
+	return errs.Err()
+}
+
+func (r RejectApprovalRequest) Validate() error {
+	var errs validationErrors
+	errs.maxLength("reason", r.Reason, 255)
+	return errs.Err()
+}
+
+func (r CreateHoldRequest) Validate(maxTTL time.Duration) error {
+	var errs validationErrors
+	errs.requirePositive("amount", r.Amount)
+	if r.ExpiresInSeconds < 0 {
+		errs.add("expires_in_seconds", "must not be negative")
+	}
+	if maxSeconds := int64(maxTTL / time.Second); r.ExpiresInSeconds > maxSeconds {
+		errs.add("expires_in_seconds", fmt.Sprintf("must be at most %d", maxSeconds))
+	}
+	if len(r.Remarks) > 255 {
+		errs.add("remarks", "must be at most 255 characters")
+	}
+	return errs.Err()
+}
+
+func (r CaptureHoldRequest) Validate() error {
+	var errs validationErrors
+	if r.Amount != nil {
+		errs.requirePositive("amount", *r.Amount)
+	}
+	return errs.Err()
+}
+
+func (r FeePreviewRequest) Validate() error {
+	var errs validationErrors
+	if !constant.IsTransactionType(r.TransactionType) {
+		errs.add("transaction_type", "is not a known transaction type")
+	}
+	errs.requirePositive("amount", r.Amount)
+	return errs.Err()
+}
@@ -1,5 +1,72 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+)
+
 type WalletListDetailRequest struct {
 	WalletID int64 `json:"wallet_id"`
 }
+
+// CreateWalletRequest opens the wallet in constant.DefaultCurrency when Currency is not set
+type CreateWalletRequest struct {
+	Name     string `json:"name"`
+	Currency string `json:"currency,omitempty"`
+}
+
+type RenameWalletRequest struct {
+	Name string `json:"name"`
+}
+
+type WalletResponse struct {
+	WalletID         int64           `json:"wallet_id"`
+	Name             string          `json:"name"`
+	Currency         string          `json:"currency"`
+	Tier             string          `json:"tier"`
+	CustomerID       *int64          `json:"customer_id,omitempty"`
+	CurrentBalance   decimal.Decimal `json:"current_balance"`
+	HeldBalance      decimal.Decimal `json:"held_balance"`
+	UnclearedBalance decimal.Decimal `json:"uncleared_balance"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+func NewWalletResponse(wallet model.Wallet) WalletResponse {
+	return WalletResponse{
+		WalletID:         wallet.ID,
+		Name:             wallet.Name,
+		Currency:         wallet.Currency,
+		Tier:             wallet.Tier,
+		CustomerID:       wallet.CustomerID,
+		CurrentBalance:   wallet.CurrentBalance,
+		HeldBalance:      wallet.HeldBalance,
+		UnclearedBalance: wallet.UnclearedBalance,
+		CreatedAt:        wallet.CreatedAt,
+		UpdatedAt:        wallet.UpdatedAt,
+	}
+}
+
+// WalletBalanceResponse splits the ledger balance into what is held, what is still
+// clearing and what can be spent
+type WalletBalanceResponse struct {
+	WalletID         int64           `json:"wallet_id"`
+	Currency         string          `json:"currency"`
+	LedgerBalance    decimal.Decimal `json:"ledger_balance"`
+	HeldBalance      decimal.Decimal `json:"held_balance"`
+	UnclearedBalance decimal.Decimal `json:"uncleared_balance"`
+	AvailableBalance decimal.Decimal `json:"available_balance"`
+}
+
+func NewWalletBalanceResponse(wallet model.Wallet) WalletBalanceResponse {
+	return WalletBalanceResponse{
+		WalletID:         wallet.ID,
+		Currency:         wallet.Currency,
+		LedgerBalance:    wallet.CurrentBalance,
+		HeldBalance:      wallet.HeldBalance,
+		UnclearedBalance: wallet.UnclearedBalance,
+		AvailableBalance: wallet.AvailableBalance(),
+	}
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/krisnadwipayana07/restful-fintech/internal/domain/model"
+	"github.com/shopspring/decimal"
+)
+
+// SaveWalletMemberRequest adds a customer to the wallet or changes its role. SpendLimit
+// is what a spender may send out per UTC day, it is only accepted for spenders.
+type SaveWalletMemberRequest struct {
+	Role       string           `json:"role"`
+	SpendLimit *decimal.Decimal `json:"spend_limit,omitempty"`
+}
+
+type WalletMemberResponse struct {
+	WalletID   int64            `json:"wallet_id"`
+	CustomerID int64            `json:"customer_id"`
+	Role       string           `json:"role"`
+	SpendLimit *decimal.Decimal `json:"spend_limit,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+func NewWalletMemberResponse(member model.WalletMember) WalletMemberResponse {
+	return WalletMemberResponse{
+		WalletID:   member.WalletID,
+		CustomerID: member.CustomerID,
+		Role:       member.Role,
+		SpendLimit: member.SpendLimit,
+		CreatedAt:  member.CreatedAt,
+		UpdatedAt:  member.UpdatedAt,
+	}
+}
+
+type WalletMembersResponse struct {
+	WalletID int64                  `json:"wallet_id"`
+	Members  []WalletMemberResponse `json:"members"`
+}
+
+func NewWalletMembersResponse(walletID int64, members []model.WalletMember) WalletMembersResponse {
+	resp := WalletMembersResponse{
+		WalletID: walletID,
+		Members:  make([]WalletMemberResponse, 0, len(members)),
+	}
+	for _, member := range members {
+		resp.Members = append(resp.Members, NewWalletMemberResponse(member))
+	}
+	return resp
+}
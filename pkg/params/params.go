@@ -0,0 +1,22 @@
+package params
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetPathID(c echo.Context, name string) (int64, error) {
+	idString := c.Param(name)
+	if idString == "" {
+		return 0, fmt.Errorf("%s not found", name)
+	}
+
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,94 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Scheme is the Authorization scheme of a signed request:
+//
+//	Authorization: HMAC-SHA256 Credential=<client id>, Timestamp=<unix seconds>, Nonce=<nonce>, Signature=<hex>
+const Scheme = "HMAC-SHA256"
+
+var ErrMalformed = errors.New("malformed signature header")
+
+type Params struct {
+	ClientID  string
+	Timestamp int64
+	Nonce     string
+	Signature string
+}
+
+// ParseAuthorization reads the parameters of an Authorization header in the Scheme
+func ParseAuthorization(value string) (Params, error) {
+	rest, ok := strings.CutPrefix(value, Scheme+" ")
+	if !ok {
+		return Params{}, ErrMalformed
+	}
+
+	params := Params{}
+	for _, part := range strings.Split(rest, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || val == "" {
+			return Params{}, ErrMalformed
+		}
+		switch key {
+		case "Credential":
+			params.ClientID = val
+		case "Timestamp":
+			timestamp, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return Params{}, ErrMalformed
+			}
+			params.Timestamp = timestamp
+		case "Nonce":
+			params.Nonce = val
+		case "Signature":
+			params.Signature = val
+		}
+	}
+
+	if params.ClientID == "" || params.Timestamp == 0 || params.Nonce == "" || params.Signature == "" {
+		return Params{}, ErrMalformed
+	}
+	return params, nil
+}
+
+// BodyHash is the hex SHA-256 of the raw request body, an empty body hashes too
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// StringToSign joins what the signature covers, path includes the query string
+func StringToSign(method string, path string, bodyHash string, timestamp int64, nonce string) string {
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		bodyHash,
+		strconv.FormatInt(timestamp, 10),
+		nonce,
+	}, "\n")
+}
+
+// Sign returns the hex HMAC-SHA256 of stringToSign, clients use it to build the header
+func Sign(secret []byte, stringToSign string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify compares the signature in constant time
+func Verify(secret []byte, stringToSign string, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(stringToSign))
+	return hmac.Equal(got, mac.Sum(nil))
+}
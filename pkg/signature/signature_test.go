@@ -0,0 +1,129 @@
+package signature
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAuthorization(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Params
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			value: "HMAC-SHA256 Credential=client-1, Timestamp=1700000000, Nonce=abc, Signature=deadbeef",
+			want:  Params{ClientID: "client-1", Timestamp: 1700000000, Nonce: "abc", Signature: "deadbeef"},
+		},
+		{
+			name:  "unknown parameters are ignored",
+			value: "HMAC-SHA256 Credential=client-1,Timestamp=1700000000,Nonce=abc,Signature=deadbeef,Version=2",
+			want:  Params{ClientID: "client-1", Timestamp: 1700000000, Nonce: "abc", Signature: "deadbeef"},
+		},
+		{name: "other scheme", value: "Bearer token", wantErr: true},
+		{name: "missing signature", value: "HMAC-SHA256 Credential=client-1, Timestamp=1700000000, Nonce=abc", wantErr: true},
+		{name: "empty value", value: "HMAC-SHA256 Credential=, Timestamp=1700000000, Nonce=abc, Signature=deadbeef", wantErr: true},
+		{name: "timestamp not a number", value: "HMAC-SHA256 Credential=client-1, Timestamp=now, Nonce=abc, Signature=deadbeef", wantErr: true},
+		{name: "part without value", value: "HMAC-SHA256 Credential=client-1, Timestamp, Nonce=abc, Signature=deadbeef", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAuthorization(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrMalformed) {
+					t.Fatalf("err = %v, want ErrMalformed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("params = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("client-secret")
+	body := []byte(`{"amount":"10"}`)
+	signed := Sign(secret, StringToSign("post", "/api/v1/withdraw?x=1", BodyHash(body), 1700000000, "abc"))
+
+	tests := []struct {
+		name         string
+		secret       []byte
+		stringToSign string
+		signature    string
+		want         bool
+	}{
+		{
+			name:         "valid",
+			secret:       secret,
+			stringToSign: StringToSign("POST", "/api/v1/withdraw?x=1", BodyHash(body), 1700000000, "abc"),
+			signature:    signed,
+			want:         true,
+		},
+		{
+			name:         "tampered body",
+			secret:       secret,
+			stringToSign: StringToSign("POST", "/api/v1/withdraw?x=1", BodyHash([]byte(`{"amount":"1000"}`)), 1700000000, "abc"),
+			signature:    signed,
+		},
+		{
+			name:         "tampered path",
+			secret:       secret,
+			stringToSign: StringToSign("POST", "/api/v1/transfer?x=1", BodyHash(body), 1700000000, "abc"),
+			signature:    signed,
+		},
+		{
+			name:         "tampered query",
+			secret:       secret,
+			stringToSign: StringToSign("POST", "/api/v1/withdraw?x=2", BodyHash(body), 1700000000, "abc"),
+			signature:    signed,
+		},
+		{
+			name:         "other timestamp",
+			secret:       secret,
+			stringToSign: StringToSign("POST", "/api/v1/withdraw?x=1", BodyHash(body), 1700000001, "abc"),
+			signature:    signed,
+		},
+		{
+			name:         "other nonce",
+			secret:       secret,
+			stringToSign: StringToSign("POST", "/api/v1/withdraw?x=1", BodyHash(body), 1700000000, "abd"),
+			signature:    signed,
+		},
+		{
+			name:         "other secret",
+			secret:       []byte("another-secret"),
+			stringToSign: StringToSign("POST", "/api/v1/withdraw?x=1", BodyHash(body), 1700000000, "abc"),
+			signature:    signed,
+		},
+		{
+			name:         "signature not hex",
+			secret:       secret,
+			stringToSign: StringToSign("POST", "/api/v1/withdraw?x=1", BodyHash(body), 1700000000, "abc"),
+			signature:    "not-hex",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.stringToSign, tt.signature); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyHashOfEmptyBody(t *testing.T) {
+	// SHA-256 of nothing, a GET is signed over it
+	const want = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if got := BodyHash(nil); got != want {
+		t.Errorf("BodyHash(nil) = %s, want %s", got, want)
+	}
+}
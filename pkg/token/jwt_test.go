@@ -0,0 +1,98 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("jwt-secret")
+
+func encodeSegment(raw string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func signHS256(secret []byte, signingInput string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newToken builds a compact JWT from raw JSON segments, signed with HS256 whatever alg says
+func newToken(secret []byte, header string, claims string) string {
+	signingInput := encodeSegment(header) + "." + encodeSegment(claims)
+	return signingInput + "." + signHS256(secret, signingInput)
+}
+
+func TestVerifyHS256(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	valid := `{"sub":"7","exp":1700000600}`
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+	unsigned := encodeSegment(`{"alg":"none","typ":"JWT"}`) + "." + encodeSegment(valid)
+
+	tests := []struct {
+		name    string
+		token   string
+		want    Claims
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: newToken(testSecret, hs256, valid),
+			want:  Claims{Subject: "7", ExpiresAt: 1700000600},
+		},
+		{
+			name:  "valid with nbf in the past",
+			token: newToken(testSecret, hs256, `{"sub":"7","exp":1700000600,"nbf":1699999000,"iat":1699999000}`),
+			want:  Claims{Subject: "7", ExpiresAt: 1700000600, NotBefore: 1699999000, IssuedAt: 1699999000},
+		},
+		{name: "alg none without signature", token: unsigned + ".", wantErr: ErrUnsupportedAlg},
+		{name: "alg none with signature", token: unsigned + "." + signHS256(testSecret, unsigned), wantErr: ErrUnsupportedAlg},
+		{name: "alg None", token: newToken(testSecret, `{"alg":"None"}`, valid), wantErr: ErrUnsupportedAlg},
+		// An RS256 token HMAC signed with the public key as secret must not pass as HS256
+		{name: "alg confusion RS256", token: newToken(testSecret, `{"alg":"RS256","typ":"JWT"}`, valid), wantErr: ErrUnsupportedAlg},
+		{name: "alg HS512", token: newToken(testSecret, `{"alg":"HS512","typ":"JWT"}`, valid), wantErr: ErrUnsupportedAlg},
+		{name: "alg missing", token: newToken(testSecret, `{"typ":"JWT"}`, valid), wantErr: ErrUnsupportedAlg},
+		{name: "wrong secret", token: newToken([]byte("other-secret"), hs256, valid), wantErr: ErrInvalidSignature},
+		{
+			name:    "tampered claims",
+			token:   encodeSegment(hs256) + "." + encodeSegment(`{"sub":"1","exp":1700000600}`) + "." + strings.Split(newToken(testSecret, hs256, valid), ".")[2],
+			wantErr: ErrInvalidSignature,
+		},
+		{name: "empty signature", token: encodeSegment(hs256) + "." + encodeSegment(valid) + ".", wantErr: ErrInvalidSignature},
+		{name: "signature not base64url", token: encodeSegment(hs256) + "." + encodeSegment(valid) + ".!!!", wantErr: ErrMalformed},
+		{name: "expired", token: newToken(testSecret, hs256, `{"sub":"7","exp":1699999999}`), wantErr: ErrExpired},
+		{name: "expires now", token: newToken(testSecret, hs256, `{"sub":"7","exp":1700000000}`), wantErr: ErrExpired},
+		{name: "exp missing", token: newToken(testSecret, hs256, `{"sub":"7"}`), wantErr: ErrExpired},
+		{name: "nbf in the future", token: newToken(testSecret, hs256, `{"sub":"7","exp":1700000600,"nbf":1700000001}`), wantErr: ErrNotYetValid},
+		{name: "empty token", token: "", wantErr: ErrMalformed},
+		{name: "one segment", token: encodeSegment(hs256), wantErr: ErrMalformed},
+		{name: "two segments", token: encodeSegment(hs256) + "." + encodeSegment(valid), wantErr: ErrMalformed},
+		{name: "four segments", token: newToken(testSecret, hs256, valid) + ".extra", wantErr: ErrMalformed},
+		{name: "header not base64url", token: "!!!." + encodeSegment(valid) + ".sig", wantErr: ErrMalformed},
+		{name: "header not json", token: newToken(testSecret, `not json`, valid), wantErr: ErrMalformed},
+		{name: "claims not json", token: newToken(testSecret, hs256, `not json`), wantErr: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyHS256(tt.token, testSecret, now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("claims = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}